@@ -1,3 +1,19 @@
 data "etcd_key" "name" {
   key = "/root/path/name"
 }
+
+# Value of the key as it was at revision 1200
+data "etcd_key" "name_at_deploy" {
+  key      = "/root/path/name"
+  revision = 1200
+}
+
+# Settings of the application, a JSON document
+data "etcd_key" "settings" {
+  key    = "/root/path/settings"
+  decode = "json"
+}
+
+locals {
+  replicas = jsondecode(data.etcd_key.settings.value_object).replicas
+}
@@ -1,3 +1,21 @@
 data "etcd_keyprefix" "all" {
     prefix = "/"
 }
+
+data "etcd_keyprefix" "latest_services" {
+  prefix      = "/services/"
+  limit       = 100
+  sort_target = "MOD"
+  sort_order  = "DESCEND"
+  keys_only   = true
+}
+
+data "etcd_keyprefix" "app" {
+  prefix      = "/config/app/"
+  allow_empty = true
+}
+
+locals {
+  app_port = data.etcd_keyprefix.app.relative_map["port"]
+  app_db   = jsondecode(data.etcd_keyprefix.app.tree)["database"]
+}
@@ -0,0 +1,11 @@
+# Keys between /app/a (included) and /app/m (excluded)
+data "etcd_range" "first_half" {
+  key       = "/app/a"
+  range_end = "/app/m"
+}
+
+# Every key greater than or equal to /app/m
+data "etcd_range" "from_key" {
+  key       = "/app/m"
+  range_end = "\u0000"
+}
@@ -0,0 +1,8 @@
+# With read_revision = "pinned" in the provider, every data source of the run
+# reads the keys at this revision.
+data "etcd_revision" "current" {
+}
+
+output "etcd_revision" {
+  value = data.etcd_revision.current.revision
+}
@@ -1,10 +1,45 @@
 provider "etcd" {
   username      = var.username    # optionally use ETCD_USERNAME env var
   password      = var.password    # optionally use ETCD_PASSWORD env var
-  etcd_endpoint = var.etcd_ep     # optionally use ETCD_ENDPOINT env var
+  endpoints     = var.endpoints   # optionally use ETCD_ENDPOINT env var, comma separated
+
+  # Or discover the endpoints from the _etcd-client-ssl._tcp and _etcd-client._tcp
+  # SRV records of a domain, instead of listing them
+  # discovery_srv      = "etcd.example.com" # optionally use ETCD_DISCOVERY_SRV env var
+  # auto_sync_interval = "5m"               # refresh the endpoints from the member list
 
   # The provider will connect using a tls session. But for some weird reason 
   # you decide skip that you can set tls to false
   # tls           = var.tls         # optionally use ETCD_TLS env var
   # ca_cert       = var.ca_cert     # optionally use ETCD_CACERT env var
+  # cert          = var.cert        # optionally use ETCD_CERT env var
+  # key           = var.key         # optionally use ETCD_KEY env var
+
+  # Or get short-lived credentials from a local command printing them as JSON
+  # credential_process = ["vault-etcd-creds", "--role", "terraform"]
+
+  # The ETCDCTL_* variables of etcdctl are read when the ETCD_* ones are not set
+
+  # Reach the cluster through a bastion proxy, HTTP CONNECT or SOCKS5
+  # proxy_url = "socks5://bastion.example.com:1080" # optionally use ETCD_PROXY_URL env var
+
+  # Keep idle connections open through load balancers, and allow large values
+  # dial_keep_alive_time   = "30s"
+  # max_call_send_msg_size = 10485760
+  # tls_min_version        = "1.3"
+
+  # Serve every data source of a run from one consistent revision
+  # read_revision = "pinned"        # optionally use ETCD_READ_REVISION env var
+
+  # Let data sources read from followers, e.g. while the cluster has no leader
+  # consistency   = "serializable"  # optionally use ETCD_CONSISTENCY env var
+
+  # Scope every key of this provider to the subtree of a team
+  # namespace     = "/teams/payments" # optionally use ETCD_NAMESPACE env var
+
+  # Guardrails enforced at plan and apply time
+  # read_only        = true              # optionally use ETCD_READ_ONLY env var
+  # allowed_prefixes = ["/app/"]
+  # denied_prefixes  = ["/app/secrets/"]
+  # protected_keys   = ["/app/config/version"]
 }
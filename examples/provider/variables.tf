@@ -5,6 +5,7 @@ variable "password" {
 }
 
 variable "endpoints" {
+  type = list(string)
 }
 
 variable "tls" {
@@ -0,0 +1,7 @@
+# config/app/database.yaml is written to /config/app/database.yaml, and so on
+resource "etcd_directory_sync" "config" {
+  source_dir = "${path.module}/config"
+  prefix     = "/config/"
+  include    = ["**/*.yaml", "**/*.json"]
+  exclude    = ["**/test/**"]
+}
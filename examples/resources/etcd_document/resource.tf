@@ -0,0 +1,18 @@
+# Written as /app/config/database/host, /app/config/database/port,
+# /app/config/replicas and /app/config/zones/0 to /app/config/zones/1
+resource "etcd_document" "app_config" {
+  prefix = "/app/config/"
+  document_json = jsonencode({
+    database = {
+      host = "db.internal"
+      port = 5432
+    }
+    replicas = 3
+    zones    = ["eu-west-1a", "eu-west-1b"]
+  })
+}
+
+resource "etcd_document" "app_defaults" {
+  prefix        = "/app/defaults/"
+  document_yaml = file("defaults.yaml")
+}
@@ -2,3 +2,21 @@ resource etcd_key "test_key" {
   key   = "/test/terraform/key1"
   value = "Hello"
 }
+
+resource etcd_key "settings" {
+  key = "/test/terraform/settings"
+  value_json = jsonencode({
+    replicas = 3
+    labels   = ["blue", "green"]
+  })
+}
+
+resource etcd_key "descriptors" {
+  key          = "/test/terraform/descriptors"
+  value_base64 = filebase64("descriptors.pb")
+}
+
+resource etcd_key "nginx_template" {
+  key    = "/test/terraform/nginx.conf.tmpl"
+  source = "${path.module}/templates/nginx.conf.tmpl"
+}
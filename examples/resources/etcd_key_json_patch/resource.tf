@@ -0,0 +1,17 @@
+# Own the "x" feature flag inside a document shared with other teams
+resource "etcd_key_json_patch" "feature_x" {
+  key  = "/app/settings"
+  path = "/features/x"
+  value_json = jsonencode({
+    enabled = true
+    rollout = 25
+  })
+}
+
+# Raise the connection limit, whatever else the document holds
+resource "etcd_key_json_patch" "limits" {
+  key = "/app/settings"
+  merge_patch = jsonencode({
+    limits = { connections = 500 }
+  })
+}
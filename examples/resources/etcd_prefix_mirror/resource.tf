@@ -0,0 +1,7 @@
+# Promote the validated configuration of staging to production
+resource "etcd_prefix_mirror" "promote_config" {
+  source_cluster     = "staging"
+  source_prefix      = "/app/config/"
+  cluster            = "production"
+  destination_prefix = "/app/config/"
+}
@@ -0,0 +1,19 @@
+# Publish version 42 of the configuration and point readers to it together,
+# unless another apply already moved the pointer since version 41
+resource "etcd_txn" "publish_config" {
+  compare {
+    key    = "/app/config/current"
+    target = "VALUE"
+    value  = "41"
+  }
+
+  success {
+    key   = "/app/config/versions/42"
+    value = file("config.json")
+  }
+
+  success {
+    key   = "/app/config/current"
+    value = "42"
+  }
+}
@@ -0,0 +1,223 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/namespace"
+)
+
+const (
+	readRevisionLatest = "latest"
+	readRevisionPinned = "pinned"
+
+	consistencyLinearizable = "linearizable"
+	consistencySerializable = "serializable"
+)
+
+// clientRegistry is handed by configure to every resource and data source. It
+// holds the client of every cluster of the provider configuration, indexed by
+// name, the top-level connection being named "".
+type clientRegistry struct {
+	clients map[string]*apiClient
+	// defaultCluster is the cluster of the resources and data sources without
+	// cluster argument: the top-level connection when configured, or else the
+	// first cluster block.
+	defaultCluster string
+}
+
+// client returns the client of the cluster called name, or of the default
+// cluster when name is empty, connecting it if needed.
+func (r *clientRegistry) client(name string) (*apiClient, error) {
+	if name == "" {
+		name = r.defaultCluster
+	}
+	c, ok := r.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("no cluster named %q in the provider configuration", name)
+	}
+	if err := c.connect(); err != nil {
+		if name == "" {
+			return nil, errors.Wrap(err, "Failed connecting to etcd")
+		}
+		return nil, errors.Wrapf(err, "Failed connecting to cluster %q", name)
+	}
+	return c, nil
+}
+
+// clusterGetter is implemented by both schema.ResourceData and
+// schema.ResourceDiff.
+type clusterGetter interface {
+	Get(key string) interface{}
+}
+
+// clientFor returns the client of the cluster selected by the cluster argument
+// of d.
+func clientFor(d clusterGetter, meta interface{}) (*apiClient, error) {
+	r, ok := meta.(*clientRegistry)
+	if !ok {
+		return nil, fmt.Errorf("the etcd provider is not configured")
+	}
+	return r.client(d.Get("cluster").(string))
+}
+
+// clusterSchema is the argument selecting the cluster of a resource or data
+// source.
+func clusterSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Name of the `cluster` block of the provider to use. Defaults to the top-level connection " +
+			"settings of the provider, or to its first `cluster` block when it has none.",
+		Type:     schema.TypeString,
+		Optional: true,
+	}
+}
+
+// resourceClusterSchema is clusterSchema for resources, which are recreated
+// when moved to another cluster.
+func resourceClusterSchema() *schema.Schema {
+	s := clusterSchema()
+	s.ForceNew = true
+	return s
+}
+
+// importStateCluster imports the resource of ID "<cluster>|<id>" in that
+// cluster, and any other ID in the default cluster.
+func importStateCluster(_ context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if r, ok := meta.(*clientRegistry); ok {
+		if i := strings.Index(d.Id(), "|"); i > 0 {
+			if _, ok := r.clients[d.Id()[:i]]; ok {
+				if err := d.Set("cluster", d.Id()[:i]); err != nil {
+					return nil, err
+				}
+				d.SetId(d.Id()[i+1:])
+			}
+		}
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+// apiClient is the client of one cluster.
+type apiClient struct {
+	*clientv3.Client
+
+	// config creates Client on first use, see connect.
+	config      clientv3.Config
+	connectOnce sync.Once
+	connectErr  error
+
+	// namespace prefixes every key of the provider.
+	namespace string
+	// guardrails restrict the writes of the provider.
+	guardrails guardrails
+	// readRevision is readRevisionLatest or readRevisionPinned.
+	readRevision string
+	// consistency is the default consistency of the data source reads.
+	consistency string
+	// requestTimeout bounds every single request of the KV and Auth APIs, see
+	// timeoutKV.
+	requestTimeout time.Duration
+
+	mu             sync.Mutex
+	pinnedRevision int64
+}
+
+// newAPIClient returns the client of the cluster of cfg, with the provider
+// settings of d. It only connects on first use.
+func newAPIClient(cfg clientv3.Config, d *schema.ResourceData) (*apiClient, error) {
+	requestTimeout, err := time.ParseDuration(d.Get("request_timeout").(string))
+	if err != nil {
+		return nil, err
+	}
+	return &apiClient{
+		config:         cfg,
+		namespace:      d.Get("namespace").(string),
+		guardrails:     newGuardrails(d),
+		readRevision:   d.Get("read_revision").(string),
+		consistency:    d.Get("consistency").(string),
+		requestTimeout: requestTimeout,
+	}, nil
+}
+
+// connect creates the etcd client the first time it is called, so a cluster
+// which cannot be reached only fails the resources and data sources using it.
+// With a namespace, the KV, Watcher and Lease APIs of the client are replaced
+// by namespaced ones, so resources and data sources keep using the keys of
+// their configuration. The KV and Auth APIs are then wrapped to apply the
+// request timeout.
+func (c *apiClient) connect() error {
+	c.connectOnce.Do(func() {
+		cli, err := clientv3.New(c.config)
+		if err != nil {
+			c.connectErr = err
+			return
+		}
+		if c.namespace != "" {
+			cli.KV = namespace.NewKV(cli.KV, c.namespace)
+			cli.Watcher = namespace.NewWatcher(cli.Watcher, c.namespace)
+			cli.Lease = namespace.NewLease(cli.Lease, c.namespace)
+		}
+		cli.KV = &timeoutKV{KV: cli.KV, timeout: c.requestTimeout}
+		cli.Auth = &timeoutAuth{Auth: cli.Auth, timeout: c.requestTimeout}
+		c.Client = cli
+	})
+	return c.connectErr
+}
+
+// namespacedRange returns the range of the whole keyspace matching the range
+// [key, rangeEnd) of the namespace. Only the KV API is namespaced by etcd, so
+// this is needed by the permissions of the auth API. An empty rangeEnd stands
+// for the single key and "\x00" for every key from key to the end of the
+// namespace.
+func (c *apiClient) namespacedRange(key, rangeEnd string) (string, string) {
+	if c.namespace == "" {
+		return key, rangeEnd
+	}
+	switch rangeEnd {
+	case "":
+		return c.namespace + key, ""
+	case "\x00":
+		return c.namespace + key, clientv3.GetPrefixRangeEnd(c.namespace)
+	}
+	return c.namespace + key, c.namespace + rangeEnd
+}
+
+// dataRevision returns the revision a data source asking for requested has to
+// read at. With read_revision = "pinned", the revision of the cluster at the
+// first data source read is recorded and served to every later read, unless the
+// data source asks for a revision of its own. 0 means the latest revision.
+func (c *apiClient) dataRevision(ctx context.Context, requested int64) (int64, error) {
+	if requested > 0 || c.readRevision != readRevisionPinned {
+		return requested, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pinnedRevision == 0 {
+		resp, err := c.Get(ctx, "\x00", append(c.readOptions(""), clientv3.WithCountOnly())...)
+		if err != nil {
+			return 0, err
+		}
+		c.pinnedRevision = resp.Header.Revision
+	}
+	return c.pinnedRevision, nil
+}
+
+// readOptions returns the options of a data source read with the given
+// consistency, falling back to the provider consistency when it is empty.
+// Serializable reads are served by any member, even without a leader, at the
+// cost of possibly stale data.
+func (c *apiClient) readOptions(consistency string) []clientv3.OpOption {
+	if consistency == "" {
+		consistency = c.consistency
+	}
+	if consistency == consistencySerializable {
+		return []clientv3.OpOption{clientv3.WithSerializable()}
+	}
+	return nil
+}
@@ -1,9 +1,17 @@
 package provider
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	uuid "github.com/satori/go.uuid"
 )
 
+// defaultTimeout bounds a whole resource or data source operation unless its
+// timeouts block says otherwise.
+const defaultTimeout = 5 * time.Minute
+
 //uuidGenerator return random uuid nn a string format that are intended to be used as unique identifiers.
 func uuidGenerator() string {
 	uu := uuid.NewV4()
@@ -19,3 +27,37 @@ func contains(s []string, str string) bool {
 
 	return false
 }
+
+func validateIntAtLeast(min int) schema.SchemaValidateFunc {
+	return func(val interface{}, key string) (warns []string, errs []error) {
+		v := val.(int)
+		if v < min {
+			errs = append(errs, fmt.Errorf("%q must be at least %d, got: %d", key, min, v))
+		}
+		return
+	}
+}
+
+func validateNotEmpty(val interface{}, key string) (warns []string, errs []error) {
+	if val.(string) == "" {
+		errs = append(errs, fmt.Errorf("%q must not be empty", key))
+	}
+	return
+}
+
+func validateStringIn(values ...string) schema.SchemaValidateFunc {
+	return func(val interface{}, key string) (warns []string, errs []error) {
+		v := val.(string)
+		if !contains(values, v) {
+			errs = append(errs, fmt.Errorf("%q must be one of %v, got: %v", key, values, v))
+		}
+		return
+	}
+}
+
+func validateDuration(val interface{}, key string) (warns []string, errs []error) {
+	if _, err := time.ParseDuration(val.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%q must be a duration such as \"5s\", got: %v", key, val))
+	}
+	return
+}
@@ -0,0 +1,255 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/client/pkg/v3/srv"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/transport"
+	"google.golang.org/grpc"
+)
+
+// tlsVersions are the TLS versions tls_min_version accepts.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// srvService is the service of the DNS SRV records announcing the client URLs
+// of a cluster, _etcd-client._tcp and _etcd-client-ssl._tcp, as in etcdctl.
+const srvService = "etcd-client"
+
+// errNoEndpoint is returned by clientEndpoints when a connection has no
+// endpoint at all.
+var errNoEndpoint = errors.New("no etcd endpoint configured: set endpoints, discovery_srv or the ETCD_ENDPOINT environment variable")
+
+// clusterSettings are the settings a cluster block sets for its own connection.
+// Every other setting is shared with the top-level connection of the provider.
+var clusterSettings = []string{
+	"endpoints", "discovery_srv", "discovery_srv_name", "username", "password", "credential_process",
+	"tls", "ca_cert", "cert", "key", "insecure_skip_tls_verify",
+}
+
+// connectionSettings reads the settings of a connection to a cluster: the
+// top-level ones of the provider, or the ones of a cluster block.
+type connectionSettings struct {
+	d *schema.ResourceData
+	// prefix is the path of the cluster block, e.g. "cluster.0.", or empty for
+	// the top-level connection.
+	prefix string
+}
+
+func (s connectionSettings) Get(key string) interface{} {
+	if s.prefix != "" && contains(clusterSettings, key) {
+		return s.d.Get(s.prefix + key)
+	}
+	return s.d.Get(key)
+}
+
+// clientConfig builds the configuration of the etcd client from the connection
+// settings s.
+func clientConfig(ctx context.Context, s connectionSettings) (clientv3.Config, error) {
+	var cfg clientv3.Config
+
+	endpoints, err := clientEndpoints(s)
+	if err != nil {
+		return cfg, err
+	}
+
+	username := s.Get("username").(string)
+	password := s.Get("password").(string)
+
+	var creds *credentialProcess
+	var processCert bool
+	if command := stringList(s.Get("credential_process").([]interface{})); len(command) > 0 {
+		creds = newCredentialProcess(command)
+		c, cert, err := creds.get(ctx)
+		if err != nil {
+			return cfg, err
+		}
+		if c.Username != "" {
+			// the interceptor of the credential process authenticates the
+			// requests, the client must not do it with credentials of its own
+			username, password = "", ""
+		}
+		processCert = cert != nil
+	}
+	if (username == "") != (password == "") {
+		return cfg, fmt.Errorf("username and password must be set together")
+	}
+
+	dialTimeout, err := time.ParseDuration(s.Get("dial_timeout").(string))
+	if err != nil {
+		return cfg, err
+	}
+	retryMaxBackoff, err := time.ParseDuration(s.Get("retry_max_backoff").(string))
+	if err != nil {
+		return cfg, err
+	}
+	autoSyncInterval, err := time.ParseDuration(s.Get("auto_sync_interval").(string))
+	if err != nil {
+		return cfg, err
+	}
+	keepAliveTime, err := time.ParseDuration(s.Get("dial_keep_alive_time").(string))
+	if err != nil {
+		return cfg, err
+	}
+	keepAliveTimeout, err := time.ParseDuration(s.Get("dial_keep_alive_timeout").(string))
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg = clientv3.Config{
+		Endpoints:            endpoints,
+		AutoSyncInterval:     autoSyncInterval,
+		DialTimeout:          dialTimeout,
+		DialKeepAliveTime:    keepAliveTime,
+		DialKeepAliveTimeout: keepAliveTimeout,
+		PermitWithoutStream:  s.Get("permit_without_stream").(bool),
+		MaxCallSendMsgSize:   s.Get("max_call_send_msg_size").(int),
+		MaxCallRecvMsgSize:   s.Get("max_call_recv_msg_size").(int),
+		RejectOldCluster:     s.Get("reject_old_cluster").(bool),
+		Username:             username,
+		Password:             password,
+	}
+
+	interceptors := []grpc.UnaryClientInterceptor{
+		retryInterceptor(s.Get("max_retries").(int), retryMaxBackoff),
+	}
+	if creds != nil {
+		interceptors = append([]grpc.UnaryClientInterceptor{creds.interceptor()}, interceptors...)
+	}
+	cfg.DialOptions = []grpc.DialOption{grpc.WithChainUnaryInterceptor(interceptors...)}
+
+	if proxyURL := s.Get("proxy_url").(string); proxyURL != "" {
+		dialer, err := proxyDialer(proxyURL)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithContextDialer(dialer))
+	}
+
+	if s.Get("tls").(bool) {
+		tlsInfo := transport.TLSInfo{
+			CertFile:           s.Get("cert").(string),
+			KeyFile:            s.Get("key").(string),
+			TrustedCAFile:      s.Get("ca_cert").(string),
+			InsecureSkipVerify: s.Get("insecure_skip_tls_verify").(bool),
+		}
+		if (tlsInfo.CertFile == "") != (tlsInfo.KeyFile == "") {
+			return cfg, fmt.Errorf("cert and key must be set together")
+		}
+		cfg.TLS, err = tlsInfo.ClientConfig()
+		if err != nil {
+			return cfg, err
+		}
+		cfg.TLS.MinVersion = tlsVersions[s.Get("tls_min_version").(string)]
+		for _, name := range stringList(s.Get("tls_cipher_suites").([]interface{})) {
+			cfg.TLS.CipherSuites = append(cfg.TLS.CipherSuites, cipherSuites()[name])
+		}
+		if processCert {
+			cfg.TLS.GetClientCertificate = creds.getClientCertificate
+		}
+	} else if processCert {
+		return cfg, fmt.Errorf("the credential process printed a certificate but tls is disabled")
+	}
+
+	return cfg, nil
+}
+
+// clientEndpoints returns the endpoints of the cluster: the endpoints argument,
+// or else the comma separated ETCD_ENDPOINT or ETCDCTL_ENDPOINTS environment
+// variable for the top-level connection, or else the client URLs announced by
+// the SRV records of discovery_srv.
+func clientEndpoints(s connectionSettings) ([]string, error) {
+	endpoints := stringList(s.Get("endpoints").([]interface{}))
+
+	if domain := s.Get("discovery_srv").(string); domain != "" {
+		srvs, err := srv.GetClient(srvService, domain, s.Get("discovery_srv_name").(string))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed discovering the etcd endpoints of %q", domain)
+		}
+		endpoints = srvs.Endpoints
+	} else if len(endpoints) == 0 && s.prefix == "" {
+		for _, env := range []string{"ETCD_ENDPOINT", "ETCDCTL_ENDPOINTS"} {
+			if v := os.Getenv(env); v != "" {
+				endpoints = strings.Split(v, ",")
+				break
+			}
+		}
+	}
+
+	for i, e := range endpoints {
+		endpoints[i] = strings.TrimSpace(e)
+		if endpoints[i] == "" {
+			return nil, fmt.Errorf("etcd endpoints must not be empty, got: %q", endpoints)
+		}
+		if err := validateEndpoint(endpoints[i]); err != nil {
+			return nil, err
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, errNoEndpoint
+	}
+	return endpoints, nil
+}
+
+// etcdctlUserDefaultFunc returns the default of the username or password
+// argument: the env environment variable, or else the part of ETCDCTL_USER,
+// "user:password" as in etcdctl, at index part. A password missing from
+// ETCDCTL_USER is read from ETCDCTL_PASSWORD.
+func etcdctlUserDefaultFunc(env string, part int) schema.SchemaDefaultFunc {
+	return func() (interface{}, error) {
+		if v := os.Getenv(env); v != "" {
+			return v, nil
+		}
+
+		user := os.Getenv("ETCDCTL_USER")
+		if user == "" {
+			return nil, nil
+		}
+		parts := strings.SplitN(user, ":", 2)
+		if len(parts) == 1 {
+			parts = append(parts, os.Getenv("ETCDCTL_PASSWORD"))
+		}
+		if parts[part] == "" {
+			return nil, nil
+		}
+		return parts[part], nil
+	}
+}
+
+func tlsVersionNames() []string {
+	names := make([]string, 0, len(tlsVersions))
+	for name := range tlsVersions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cipherSuites returns the secure cipher suites implemented by Go, indexed by
+// IANA name.
+func cipherSuites() map[string]uint16 {
+	suites := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	return suites
+}
+
+func cipherSuiteNames() []string {
+	var names []string
+	for _, s := range tls.CipherSuites() {
+		names = append(names, s.Name)
+	}
+	return names
+}
@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pkg/errors"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// credentialProcessTimeout bounds a single run of the credential process.
+	credentialProcessTimeout = time.Minute
+	// credentialRefreshSkew is how long before they expire credentials are
+	// renewed, so no request is sent with credentials about to expire.
+	credentialRefreshSkew = 30 * time.Second
+)
+
+// processCredentials is the JSON document printed by the credential process.
+// Every field is optional, credentials without expires_at never expire.
+type processCredentials struct {
+	Username  string     `json:"username"`
+	Password  string     `json:"password"`
+	CertPEM   string     `json:"cert_pem"`
+	KeyPEM    string     `json:"key_pem"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// credentialProcess runs an external command printing the credentials of the
+// provider, and runs it again whenever they expire.
+type credentialProcess struct {
+	command []string
+
+	mu    sync.Mutex
+	creds *processCredentials
+	cert  *tls.Certificate
+
+	// authMu guards the auth token, obtained with the username and password
+	// of tokenCreds. It is distinct from mu, which the TLS handshake of the
+	// connection authenticating may need.
+	authMu     sync.Mutex
+	token      string
+	tokenCreds *processCredentials
+}
+
+func newCredentialProcess(command []string) *credentialProcess {
+	return &credentialProcess{command: command}
+}
+
+// get returns the current credentials, running the command first when they
+// are missing or about to expire.
+func (p *credentialProcess) get(ctx context.Context) (*processCredentials, *tls.Certificate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.creds != nil && (p.creds.ExpiresAt == nil || time.Until(*p.creds.ExpiresAt) > credentialRefreshSkew) {
+		return p.creds, p.cert, nil
+	}
+
+	creds, err := p.run(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cert *tls.Certificate
+	if creds.CertPEM != "" || creds.KeyPEM != "" {
+		c, err := tls.X509KeyPair([]byte(creds.CertPEM), []byte(creds.KeyPEM))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Failed loading the certificate of the credential process")
+		}
+		cert = &c
+	}
+
+	p.creds = creds
+	p.cert = cert
+
+	return creds, cert, nil
+}
+
+// run runs the command and parses what it prints.
+func (p *credentialProcess) run(ctx context.Context) (*processCredentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, credentialProcessTimeout)
+	defer cancel()
+
+	tflog.Debug(ctx, fmt.Sprintf("Running the credential process %q", p.command[0]))
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "Failed running the credential process %q: %s", p.command[0], strings.TrimSpace(stderr.String()))
+	}
+
+	var creds processCredentials
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return nil, errors.Wrapf(err, "Failed parsing the output of the credential process %q", p.command[0])
+	}
+	if (creds.Username == "") != (creds.Password == "") {
+		return nil, fmt.Errorf("the credential process %q must print both username and password, or none", p.command[0])
+	}
+	if creds.Username == "" && creds.CertPEM == "" {
+		return nil, fmt.Errorf("the credential process %q printed neither username and password nor cert_pem and key_pem", p.command[0])
+	}
+
+	return &creds, nil
+}
+
+// getClientCertificate is the tls.Config hook presenting the certificate of
+// the credential process on every new connection.
+func (p *credentialProcess) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	_, cert, err := p.get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return &tls.Certificate{}, nil
+	}
+	return cert, nil
+}
+
+// authenticateMethod is the RPC exchanging a username and password for an auth
+// token.
+const authenticateMethod = "/etcdserverpb.Auth/Authenticate"
+
+// authToken returns the auth token of the username and password of creds,
+// authenticating first when there is none yet, the credentials were renewed,
+// or the server rejected the token stale.
+func (p *credentialProcess) authToken(ctx context.Context, creds *processCredentials, stale string, cc *grpc.ClientConn, invoker grpc.UnaryInvoker) (string, error) {
+	p.authMu.Lock()
+	defer p.authMu.Unlock()
+
+	if p.token != "" && p.token != stale && p.tokenCreds == creds {
+		return p.token, nil
+	}
+
+	req := &pb.AuthenticateRequest{Name: creds.Username, Password: creds.Password}
+	var resp pb.AuthenticateResponse
+	if err := invoker(ctx, authenticateMethod, req, &resp, cc); err != nil {
+		return "", errors.Wrap(rpctypes.Error(err), "Failed authenticating with the credentials of the credential process")
+	}
+	p.token = resp.Token
+	p.tokenCreds = creds
+	return p.token, nil
+}
+
+// tokenRejected reports whether err is the server refusing the auth token of
+// a request, which authenticating again fixes.
+func tokenRejected(err error) bool {
+	switch rpctypes.Error(err) {
+	case rpctypes.ErrInvalidAuthToken, rpctypes.ErrAuthOldRevision, rpctypes.ErrUserEmpty:
+		return true
+	}
+	return false
+}
+
+// interceptor renews the credentials before sending a request when they are
+// about to expire, and authenticates the request with the username and
+// password of the credential process. They are never handed to the client,
+// which would read them without synchronization while they are renewed.
+func (p *credentialProcess) interceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		creds, _, err := p.get(ctx)
+		if err != nil {
+			return err
+		}
+		if creds.Username == "" || method == authenticateMethod {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		var token string
+		for attempt := 0; ; attempt++ {
+			token, err = p.authToken(ctx, creds, token, cc, invoker)
+			if err != nil {
+				return err
+			}
+			err = invoker(metadata.AppendToOutgoingContext(ctx, rpctypes.TokenFieldNameGRPC, token), method, req, reply, cc, opts...)
+			if attempt > 0 || !tokenRejected(err) {
+				return err
+			}
+		}
+	}
+}
@@ -2,7 +2,7 @@ package provider
 
 import (
 	"context"
-	"time"
+	"encoding/base64"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -12,7 +12,11 @@ import (
 func dataSourceKey() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: dataSourceKeyRead,
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(defaultTimeout),
+		},
 		Schema: map[string]*schema.Schema{
+			"cluster": clusterSchema(),
 			"key": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -21,6 +25,11 @@ func dataSourceKey() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"value_base64": valueBase64Schema(),
+			"value_object": valueObjectSchema(),
+			"decode":       decodeSchema(),
+			"revision":     revisionSchema(),
+			"consistency":  consistencySchema(),
 			"last_updated": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -31,20 +40,21 @@ func dataSourceKey() *schema.Resource {
 
 func dataSourceKeyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	var requestTimeout = 5 * time.Second
 
-	cli := m.(*clientv3.Client)
+	cli, err := clientFor(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	key := d.Get("key").(string)
-	resp, err := cli.Get(ctx, key)
-	cancel()
+	rev, err := cli.dataRevision(ctx, int64(d.Get("revision").(int)))
 	if err != nil {
-		return append(diag.FromErr(err), diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error reading data from etcd",
-			Detail:   "Failed calling cli.Get() from dataSourceKeyRead()",
-		})
+		return readDiagnostics(err, rev, "Failed getting the revision to read at")
+	}
+	opts := append(cli.readOptions(d.Get("consistency").(string)), clientv3.WithRev(rev))
+	resp, err := cli.Get(ctx, key, opts...)
+	if err != nil {
+		return readDiagnostics(err, rev, "Failed calling cli.Get() from dataSourceKeyRead()")
 	}
 	if resp.Count == 0 {
 		return append(diag.FromErr(err), diag.Diagnostic{
@@ -54,13 +64,27 @@ func dataSourceKeyRead(ctx context.Context, d *schema.ResourceData, m interface{
 		})
 	}
 	for _, ev := range resp.Kvs {
-		if err := d.Set("value", string(ev.Value)); err != nil {
+		if err := d.Set("value", textValue(ev.Value)); err != nil {
 			return append(diag.FromErr(err), diag.Diagnostic{
 				Severity: diag.Error,
 				Summary:  "Error reading data from etcd server",
 				Detail:   "Failed saving data into 'value'.",
 			})
 		}
+		if err := d.Set("value_base64", base64.StdEncoding.EncodeToString(ev.Value)); err != nil {
+			return diag.FromErr(err)
+		}
+		object, err := valueObject(d.Get("decode").(string), key, string(ev.Value))
+		if err != nil {
+			return append(diag.FromErr(err), diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Error decoding data from etcd",
+				Detail:   "Failed decoding 'value' into 'value_object'.",
+			})
+		}
+		if err := d.Set("value_object", object); err != nil {
+			return diag.FromErr(err)
+		}
 		//if err := d.Set("create_revision", int(ev.CreateRevision)); err != nil {
 		//	return append(diag.FromErr(err), diag.Diagnostic{
 		//		Severity: diag.Error,
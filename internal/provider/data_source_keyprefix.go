@@ -2,11 +2,14 @@ package provider
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"time"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
@@ -18,7 +21,11 @@ type Etcd_key struct {
 func dataSourceKeyPrefix() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: dataSourceKeyPrefixRead,
-		Schema: map[string]*schema.Schema{
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(defaultTimeout),
+		},
+		Schema: withRangeFilters(map[string]*schema.Schema{
+			"cluster": clusterSchema(),
 			"prefix": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -36,64 +43,137 @@ func dataSourceKeyPrefix() *schema.Resource {
 							Type:     schema.TypeString,
 							Computed: true,
 						},
+						"value_base64": valueBase64Schema(),
+						"value_object": valueObjectSchema(),
 					},
 				},
 			},
+			"map": {
+				Description: "Values of the matching keys, indexed by full key.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"relative_map": {
+				Description: "Values of the matching keys, indexed by key with the prefix stripped.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"tree": {
+				Description: "JSON document nesting the values by the `delimiter` separated segments of the relative keys. " +
+					"A key that is also the parent of other keys keeps its value under the empty attribute name. Use `jsondecode()` to consume it.",
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"delimiter": {
+				Description:  "Delimiter used to split the relative keys when building `tree`.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "/",
+				ValidateFunc: validateNotEmpty,
+			},
+			"allow_empty": {
+				Description: "Return empty results instead of an error when no key matches the prefix.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"key_count": {
+				Description: "Number of keys matching the prefix and the revision filters.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
 			"last_updated": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
-		},
+		}),
 	}
 }
 
 func dataSourceKeyPrefixRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	var requestTimeout = 5 * time.Second
 
-	cli := m.(*clientv3.Client)
+	cli, err := clientFor(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	prefix := fmt.Sprintf("%v", d.Get("prefix"))
-	resp, err := cli.Get(ctx, prefix, clientv3.WithPrefix())
-	cancel()
+	q := newRangeQuery(d, cli, prefix, clientv3.GetPrefixRangeEnd(prefix))
+
+	rev, err := cli.dataRevision(ctx, q.revision)
 	if err != nil {
-		return append(diag.FromErr(err), diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error reading data from etcd",
-			Detail:   "Failed calling cli.Get() from dataSourceKeyRead()",
-		})
+		return readDiagnostics(err, q.revision, "Failed getting the revision to read at")
+	}
+	q.revision = rev
+
+	count, rev, err := countRange(ctx, cli, q)
+	if err != nil {
+		return readDiagnostics(err, q.revision, "Failed counting keys from dataSourceKeyPrefixRead()")
 	}
-	if resp.Count == 0 {
-		return append(diag.FromErr(err), diag.Diagnostic{
+	if count == 0 && !d.Get("allow_empty").(bool) {
+		return append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Error reading data from etcd",
 			Detail:   "Etcd returns no answer. It is suppose to have at least one empty value.",
 		})
 	}
+	if err := d.Set("key_count", int(count)); err != nil {
+		return diag.FromErr(err)
+	}
 
-	entries := make([]interface{}, len(resp.Kvs), len(resp.Kvs))
-
-	for i, ev := range resp.Kvs {
-		if ev == nil {
-			return append(diag.FromErr(err), diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error reading data from etcd",
-				Detail:   "Etcd prefix returns no answer. It is suppose to have at least one empty value.",
-			})
+	var kvs []*mvccpb.KeyValue
+	if !d.Get("count_only").(bool) {
+		// read the entries at the revision they were counted at, unless a
+		// revision was requested or pinned
+		if q.revision == 0 {
+			q.revision = rev
 		}
+		kvs, _, err = getRange(ctx, cli, q)
+		if err != nil {
+			return readDiagnostics(err, q.revision, "Failed calling cli.Get() from dataSourceKeyPrefixRead()")
+		}
+	}
 
+	entries := make([]interface{}, len(kvs), len(kvs))
+	values := make(map[string]string, len(kvs))
+	relativeValues := make(map[string]string, len(kvs))
+
+	decode := d.Get("decode").(string)
+	for i, ev := range kvs {
 		entry := make(map[string]interface{})
 
 		entry["key"] = string(ev.Key)
-		entry["value"] = string(ev.Value)
+		entry["value"] = textValue(ev.Value)
+		entry["value_base64"] = base64.StdEncoding.EncodeToString(ev.Value)
+		object, objectDiags := entryValueObject(decode, string(ev.Key), string(ev.Value))
+		diags = append(diags, objectDiags...)
+		entry["value_object"] = object
 
 		entries[i] = entry
+		values[string(ev.Key)] = textValue(ev.Value)
+		relativeValues[strings.TrimPrefix(string(ev.Key), prefix)] = textValue(ev.Value)
+	}
+
+	tree, err := json.Marshal(buildTree(relativeValues, d.Get("delimiter").(string)))
+	if err != nil {
+		return diag.FromErr(err)
 	}
 
 	if err := d.Set("entries", entries); err != nil {
 		return diag.FromErr(err)
 	}
+	if err := d.Set("map", values); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("relative_map", relativeValues); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("tree", string(tree)); err != nil {
+		return diag.FromErr(err)
+	}
 
 	// always run
 	d.SetId(uuidGenerator())
@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func dataSourceRange() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRangeRead,
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(defaultTimeout),
+		},
+		Schema: withRangeFilters(map[string]*schema.Schema{
+			"cluster": clusterSchema(),
+			"key": {
+				Description: "First key of the range.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"range_end": {
+				Description: "End of the range, excluded. An empty value reads `key` only and \"\\u0000\" reads every key " +
+					"greater than or equal to `key`, like `endrange` in `etcd_permission`.",
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"entries": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"value": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"value_base64": valueBase64Schema(),
+						"value_object": valueObjectSchema(),
+					},
+				},
+			},
+			"map": {
+				Description: "Values of the keys in the range, indexed by key.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"allow_empty": {
+				Description: "Return empty results instead of an error when the range holds no key.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"key_count": {
+				Description: "Number of keys in the range matching the revision filters.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+		}),
+	}
+}
+
+func dataSourceRangeRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	cli, err := clientFor(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	q := newRangeQuery(d, cli, d.Get("key").(string), d.Get("range_end").(string))
+
+	rev, err := cli.dataRevision(ctx, q.revision)
+	if err != nil {
+		return readDiagnostics(err, q.revision, "Failed getting the revision to read at")
+	}
+	q.revision = rev
+
+	count, rev, err := countRange(ctx, cli, q)
+	if err != nil {
+		return readDiagnostics(err, q.revision, "Failed counting keys from dataSourceRangeRead()")
+	}
+	if count == 0 && !d.Get("allow_empty").(bool) {
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Error reading data from etcd",
+			Detail:   "Etcd returns no answer. It is suppose to have at least one empty value.",
+		})
+	}
+	if err := d.Set("key_count", int(count)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var kvs []*mvccpb.KeyValue
+	if !d.Get("count_only").(bool) {
+		// read the entries at the revision they were counted at, unless a
+		// revision was requested or pinned
+		if q.revision == 0 {
+			q.revision = rev
+		}
+		kvs, _, err = getRange(ctx, cli, q)
+		if err != nil {
+			return readDiagnostics(err, q.revision, "Failed calling cli.Get() from dataSourceRangeRead()")
+		}
+	}
+
+	entries := make([]interface{}, len(kvs))
+	values := make(map[string]string, len(kvs))
+
+	decode := d.Get("decode").(string)
+	for i, ev := range kvs {
+		object, objectDiags := entryValueObject(decode, string(ev.Key), string(ev.Value))
+		diags = append(diags, objectDiags...)
+		entries[i] = map[string]interface{}{
+			"key":          string(ev.Key),
+			"value":        textValue(ev.Value),
+			"value_base64": base64.StdEncoding.EncodeToString(ev.Value),
+			"value_object": object,
+		}
+		values[string(ev.Key)] = textValue(ev.Value)
+	}
+
+	if err := d.Set("entries", entries); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("map", values); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// always run
+	d.SetId(uuidGenerator())
+
+	return diags
+}
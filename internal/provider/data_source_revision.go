@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func dataSourceRevision() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRevisionRead,
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(defaultTimeout),
+		},
+		Schema: map[string]*schema.Schema{
+			"cluster": clusterSchema(),
+			"revision": {
+				Description: "Revision served to the data sources: the pinned revision when the provider sets " +
+					"`read_revision = \"pinned\"`, the current revision of the cluster otherwise.",
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"pinned": {
+				Description: "Whether `revision` is pinned for the whole run.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceRevisionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	cli, err := clientFor(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	rev, err := cli.dataRevision(ctx, 0)
+	if err != nil {
+		return readDiagnostics(err, 0, "Failed getting the revision to read at")
+	}
+	if rev == 0 {
+		resp, err := cli.Get(ctx, "\x00", append(cli.readOptions(""), clientv3.WithCountOnly())...)
+		if err != nil {
+			return readDiagnostics(err, 0, "Failed calling cli.Get() from dataSourceRevisionRead()")
+		}
+		rev = resp.Header.Revision
+	}
+
+	if err := d.Set("revision", int(rev)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("pinned", cli.readRevision == readRevisionPinned); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// always run
+	d.SetId(uuidGenerator())
+
+	return diags
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// guardrails restrict what the provider is allowed to write, whatever the
+// credentials it uses can do. Every key is checked as written in the
+// configuration, before the namespace is added.
+type guardrails struct {
+	readOnly        bool
+	allowedPrefixes []string
+	deniedPrefixes  []string
+	protectedKeys   []string
+	// allowRootChanges lets the root user and role be removed while auth is enabled.
+	allowRootChanges bool
+}
+
+func newGuardrails(d *schema.ResourceData) guardrails {
+	return guardrails{
+		readOnly:         d.Get("read_only").(bool),
+		allowedPrefixes:  stringList(d.Get("allowed_prefixes").([]interface{})),
+		deniedPrefixes:   stringList(d.Get("denied_prefixes").([]interface{})),
+		protectedKeys:    stringList(d.Get("protected_keys").([]interface{})),
+		allowRootChanges: d.Get("allow_root_changes").(bool),
+	}
+}
+
+// checkReadOnly returns an error when the provider is not allowed to write at all.
+func (g guardrails) checkReadOnly() error {
+	if g.readOnly {
+		return fmt.Errorf("the etcd provider is configured with read_only = true, changes are not allowed")
+	}
+	return nil
+}
+
+// checkWrite returns an error when key cannot be written.
+func (g guardrails) checkWrite(key string) error {
+	return g.checkRange(key, "")
+}
+
+// checkRange returns an error when the keys in [key, rangeEnd) cannot be
+// written. An empty rangeEnd stands for the single key and "\x00" for every key
+// from key onwards, as in etcd.
+func (g guardrails) checkRange(key, rangeEnd string) error {
+	if err := g.checkReadOnly(); err != nil {
+		return err
+	}
+
+	for _, p := range g.deniedPrefixes {
+		if rangesOverlap(key, rangeEnd, p, clientv3.GetPrefixRangeEnd(p)) {
+			return fmt.Errorf("key %q is under the denied prefix %q", key, p)
+		}
+	}
+
+	if len(g.allowedPrefixes) == 0 {
+		return nil
+	}
+	for _, p := range g.allowedPrefixes {
+		if strings.HasPrefix(key, p) && rangeWithin(key, rangeEnd, clientv3.GetPrefixRangeEnd(p)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("key %q is not under any of the allowed prefixes %q", key, g.allowedPrefixes)
+}
+
+// checkDelete returns an error when key cannot be deleted.
+func (g guardrails) checkDelete(key string) error {
+	if err := g.checkWrite(key); err != nil {
+		return err
+	}
+	if contains(g.protectedKeys, key) {
+		return fmt.Errorf("key %q is protected and cannot be deleted", key)
+	}
+	return nil
+}
+
+// checkRootChange refuses what, a change removing the built-in root user or
+// role, or the root role of the root user, while auth is enabled on the cluster
+// and allow_root_changes is not set.
+func (c *apiClient) checkRootChange(ctx context.Context, what string) diag.Diagnostics {
+	if c.guardrails.allowRootChanges {
+		return nil
+	}
+
+	resp, err := c.AuthStatus(ctx)
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, "Failed checking whether auth is enabled"))
+	}
+	if !resp.Enabled {
+		return nil
+	}
+
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("Refusing to %s while auth is enabled", what),
+		Detail: "etcd requires the root user, holding the root role, to exist while auth is enabled. Without it nobody " +
+			"can manage users, roles and permissions, nor disable auth, anymore, and the cluster has to be recovered " +
+			"manually. Set allow_root_changes = true in the provider configuration to do it anyway.",
+	}}
+}
+
+// rangeEndAfter returns the exclusive end of [key, rangeEnd) as a comparable
+// key, "" meaning the end of the keyspace.
+func rangeEndAfter(key, rangeEnd string) string {
+	switch rangeEnd {
+	case "":
+		return key + "\x00"
+	case "\x00":
+		return ""
+	}
+	return rangeEnd
+}
+
+// rangesOverlap reports whether [key, rangeEnd) and [start, end) share a key.
+func rangesOverlap(key, rangeEnd, start, end string) bool {
+	e1 := rangeEndAfter(key, rangeEnd)
+	e2 := rangeEndAfter(start, end)
+	return (e2 == "" || key < e2) && (e1 == "" || start < e1)
+}
+
+// rangeWithin reports whether [key, rangeEnd) ends before end.
+func rangeWithin(key, rangeEnd, end string) bool {
+	e1 := rangeEndAfter(key, rangeEnd)
+	e2 := rangeEndAfter(key, end)
+	if e2 == "" {
+		return true
+	}
+	return e1 != "" && e1 <= e2
+}
+
+// customizeDiffReadOnly refuses at plan time any change to a resource which
+// does not write keys, such as users and roles.
+func customizeDiffReadOnly(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if meta == nil || !diffHasChanges(d) {
+		return nil
+	}
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return err
+	}
+	return cli.guardrails.checkReadOnly()
+}
+
+// diffHasChanges reports whether d creates or updates the resource.
+func diffHasChanges(d *schema.ResourceDiff) bool {
+	return d.Id() == "" || len(d.GetChangedKeysPrefix("")) > 0
+}
+
+func stringList(l []interface{}) []string {
+	s := make([]string, 0, len(l))
+	for _, v := range l {
+		if v != nil {
+			s = append(s, v.(string))
+		}
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"testing"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestRangesOverlap(t *testing.T) {
+	cases := []struct {
+		name                      string
+		key, rangeEnd, start, end string
+		want                      bool
+	}{
+		{name: "same key", key: "a", start: "a", want: true},
+		{name: "other key", key: "a", start: "b", want: false},
+		{name: "key before range", key: "a", start: "b", end: "d", want: false},
+		{name: "key in range", key: "c", start: "b", end: "d", want: true},
+		{name: "key at range end", key: "d", start: "b", end: "d", want: false},
+		{name: "disjoint ranges", key: "a", rangeEnd: "b", start: "b", end: "c", want: false},
+		{name: "crossing ranges", key: "a", rangeEnd: "c", start: "b", end: "d", want: true},
+		{name: "nested ranges", key: "a", rangeEnd: "z", start: "b", end: "c", want: true},
+		{name: "range to the end", key: "m", rangeEnd: "\x00", start: "x", end: "y", want: true},
+		{name: "range to the end after", key: "m", rangeEnd: "\x00", start: "a", end: "b", want: false},
+		{name: "nested prefixes", key: "/a/", rangeEnd: clientv3.GetPrefixRangeEnd("/a/"), start: "/a/b/", end: clientv3.GetPrefixRangeEnd("/a/b/"), want: true},
+		{name: "sibling prefixes", key: "/a/", rangeEnd: clientv3.GetPrefixRangeEnd("/a/"), start: "/b/", end: clientv3.GetPrefixRangeEnd("/b/"), want: false},
+		{name: "empty prefix", key: "", rangeEnd: clientv3.GetPrefixRangeEnd(""), start: "/b/", end: clientv3.GetPrefixRangeEnd("/b/"), want: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := rangesOverlap(c.key, c.rangeEnd, c.start, c.end); got != c.want {
+				t.Errorf("rangesOverlap(%q, %q, %q, %q) = %v, want %v", c.key, c.rangeEnd, c.start, c.end, got, c.want)
+			}
+			// overlapping is symmetric
+			if got := rangesOverlap(c.start, c.end, c.key, c.rangeEnd); got != c.want {
+				t.Errorf("rangesOverlap(%q, %q, %q, %q) = %v, want %v", c.start, c.end, c.key, c.rangeEnd, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRangeWithin(t *testing.T) {
+	prefixEnd := clientv3.GetPrefixRangeEnd("/a/")
+
+	cases := []struct {
+		name               string
+		key, rangeEnd, end string
+		want               bool
+	}{
+		{name: "single key", key: "/a/b", end: prefixEnd, want: true},
+		{name: "prefix inside", key: "/a/b/", rangeEnd: clientv3.GetPrefixRangeEnd("/a/b/"), end: prefixEnd, want: true},
+		{name: "same prefix", key: "/a/", rangeEnd: prefixEnd, end: prefixEnd, want: true},
+		{name: "range past the end", key: "/a/b", rangeEnd: "/b", end: prefixEnd, want: false},
+		{name: "range to the end of the keyspace", key: "/a/b", rangeEnd: "\x00", end: prefixEnd, want: false},
+		{name: "end of the keyspace", key: "/a/b", rangeEnd: "\x00", end: "\x00", want: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := rangeWithin(c.key, c.rangeEnd, c.end); got != c.want {
+				t.Errorf("rangeWithin(%q, %q, %q) = %v, want %v", c.key, c.rangeEnd, c.end, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parseJSONPointer splits an RFC 6901 JSON pointer such as "/features/x" into
+// its unescaped reference tokens. The root pointer "" is refused, a pointer
+// always designates a part of the document.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with \"/\"", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func validateJSONPointer(val interface{}, key string) (warns []string, errs []error) {
+	if _, err := parseJSONPointer(val.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%q must be a JSON pointer: %v", key, err))
+	}
+	return
+}
+
+// decodeJSON parses a JSON document keeping its numbers as they are written, so
+// writing the document back does not alter the numbers it does not touch. Like
+// json.Unmarshal, it refuses anything after the document.
+func decodeJSON(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid data after the top-level value")
+	}
+	return v, nil
+}
+
+// jsonPointerGet returns the part of doc designated by tokens, and whether it
+// exists.
+func jsonPointerGet(doc interface{}, tokens []string) (interface{}, bool) {
+	for _, t := range tokens {
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			v, ok := d[t]
+			if !ok {
+				return nil, false
+			}
+			doc = v
+		case []interface{}:
+			i, err := strconv.Atoi(t)
+			if err != nil || i < 0 || i >= len(d) {
+				return nil, false
+			}
+			doc = d[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// jsonPointerSet sets the part of doc designated by tokens to value, creating
+// the missing objects along the way, and returns the updated document. The
+// length of an array as index appends to the array.
+func jsonPointerSet(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	t := tokens[0]
+	switch d := doc.(type) {
+	case nil:
+		v, err := jsonPointerSet(nil, tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{t: v}, nil
+	case map[string]interface{}:
+		v, err := jsonPointerSet(d[t], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		d[t] = v
+		return d, nil
+	case []interface{}:
+		i, err := strconv.Atoi(t)
+		if err != nil || i < 0 || i > len(d) {
+			return nil, fmt.Errorf("%q is not an index of an array of %d elements", t, len(d))
+		}
+		if i == len(d) {
+			v, err := jsonPointerSet(nil, tokens[1:], value)
+			if err != nil {
+				return nil, err
+			}
+			return append(d, v), nil
+		}
+		v, err := jsonPointerSet(d[i], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		d[i] = v
+		return d, nil
+	}
+	return nil, fmt.Errorf("cannot set %q inside %s, which is not an object or an array", t, jsonString(doc))
+}
+
+// jsonPointerRemove removes the part of doc designated by tokens, and returns
+// the updated document and whether anything was removed.
+func jsonPointerRemove(doc interface{}, tokens []string) (interface{}, bool) {
+	if len(tokens) == 0 {
+		return doc, false
+	}
+	parent, ok := jsonPointerGet(doc, tokens[:len(tokens)-1])
+	if !ok {
+		return doc, false
+	}
+
+	t := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := p[t]; !ok {
+			return doc, false
+		}
+		delete(p, t)
+		return doc, true
+	case []interface{}:
+		i, err := strconv.Atoi(t)
+		if err != nil || i < 0 || i >= len(p) {
+			return doc, false
+		}
+		// the array is replaced by a shorter one, set it back in its parent
+		v, err := jsonPointerSet(doc, tokens[:len(tokens)-1], append(p[:i:i], p[i+1:]...))
+		if err != nil {
+			return doc, false
+		}
+		return v, true
+	}
+	return doc, false
+}
+
+// jsonString returns v encoded as JSON, for error messages.
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
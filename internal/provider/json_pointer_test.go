@@ -0,0 +1,257 @@
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestParseJSONPointer(t *testing.T) {
+	cases := []struct {
+		pointer string
+		want    []string
+		err     bool
+	}{
+		{pointer: "/a", want: []string{"a"}},
+		{pointer: "/a/0/b", want: []string{"a", "0", "b"}},
+		{pointer: "/", want: []string{""}},
+		{pointer: "/a~1b/c~0d", want: []string{"a/b", "c~d"}},
+		{pointer: "/~01", want: []string{"~1"}},
+		{pointer: "", err: true},
+		{pointer: "a/b", err: true},
+	}
+
+	for _, c := range cases {
+		got, err := parseJSONPointer(c.pointer)
+		if c.err {
+			if err == nil {
+				t.Errorf("parseJSONPointer(%q) = %q, want an error", c.pointer, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseJSONPointer(%q): %v", c.pointer, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parseJSONPointer(%q) = %q, want %q", c.pointer, got, c.want)
+		}
+	}
+}
+
+// mustDecodeJSON decodes the JSON document s, failing the test when it is not
+// valid.
+func mustDecodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	v, err := decodeJSON([]byte(s))
+	if err != nil {
+		t.Fatalf("decoding %s: %v", s, err)
+	}
+	return v
+}
+
+func TestJSONPointerGet(t *testing.T) {
+	const doc = `{"a": {"b": [1, {"c": "d"}]}, "e": null}`
+
+	cases := []struct {
+		pointer string
+		want    string
+		ok      bool
+	}{
+		{pointer: "/a/b/0", want: `1`, ok: true},
+		{pointer: "/a/b/1/c", want: `"d"`, ok: true},
+		{pointer: "/a/b", want: `[1, {"c": "d"}]`, ok: true},
+		{pointer: "/e", want: `null`, ok: true},
+		{pointer: "/a/b/2", ok: false},
+		{pointer: "/a/b/-1", ok: false},
+		{pointer: "/a/b/x", ok: false},
+		{pointer: "/a/x", ok: false},
+		{pointer: "/a/b/0/c", ok: false},
+	}
+
+	for _, c := range cases {
+		tokens, err := parseJSONPointer(c.pointer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := jsonPointerGet(mustDecodeJSON(t, doc), tokens)
+		if ok != c.ok {
+			t.Errorf("jsonPointerGet(%s) found %v, want %v", c.pointer, ok, c.ok)
+			continue
+		}
+		if ok && !reflect.DeepEqual(got, mustDecodeJSON(t, c.want)) {
+			t.Errorf("jsonPointerGet(%s) = %s, want %s", c.pointer, jsonString(got), c.want)
+		}
+	}
+}
+
+func TestJSONPointerSet(t *testing.T) {
+	cases := []struct {
+		name    string
+		doc     string
+		pointer string
+		value   string
+		want    string
+		err     bool
+	}{
+		{name: "replace", doc: `{"a": 1}`, pointer: "/a", value: `2`, want: `{"a": 2}`},
+		{name: "add", doc: `{"a": 1}`, pointer: "/b", value: `"x"`, want: `{"a": 1, "b": "x"}`},
+		{name: "create missing objects", doc: `{}`, pointer: "/a/b/c", value: `true`, want: `{"a": {"b": {"c": true}}}`},
+		{name: "array element", doc: `{"a": [1, 2]}`, pointer: "/a/1", value: `3`, want: `{"a": [1, 3]}`},
+		{name: "array append", doc: `{"a": [1, 2]}`, pointer: "/a/2", value: `3`, want: `{"a": [1, 2, 3]}`},
+		{name: "array out of range", doc: `{"a": [1, 2]}`, pointer: "/a/3", value: `3`, err: true},
+		{name: "array not an index", doc: `{"a": [1, 2]}`, pointer: "/a/x", value: `3`, err: true},
+		{name: "inside a value", doc: `{"a": 1}`, pointer: "/a/b", value: `2`, err: true},
+		{name: "large number kept", doc: `{"a": 9007199254740993}`, pointer: "/b", value: `1`, want: `{"a": 9007199254740993, "b": 1}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tokens, err := parseJSONPointer(c.pointer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := jsonPointerSet(mustDecodeJSON(t, c.doc), tokens, mustDecodeJSON(t, c.value))
+			if c.err {
+				if err == nil {
+					t.Fatalf("jsonPointerSet(%s, %s) = %s, want an error", c.doc, c.pointer, jsonString(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, mustDecodeJSON(t, c.want)) {
+				t.Errorf("jsonPointerSet(%s, %s) = %s, want %s", c.doc, c.pointer, jsonString(got), c.want)
+			}
+		})
+	}
+}
+
+func TestJSONPointerRemove(t *testing.T) {
+	cases := []struct {
+		name    string
+		doc     string
+		pointer string
+		want    string
+		removed bool
+	}{
+		{name: "attribute", doc: `{"a": 1, "b": 2}`, pointer: "/a", want: `{"b": 2}`, removed: true},
+		{name: "nested attribute", doc: `{"a": {"b": 1, "c": 2}}`, pointer: "/a/b", want: `{"a": {"c": 2}}`, removed: true},
+		{name: "array element", doc: `{"a": [1, 2, 3]}`, pointer: "/a/1", want: `{"a": [1, 3]}`, removed: true},
+		{name: "missing attribute", doc: `{"a": 1}`, pointer: "/b", want: `{"a": 1}`, removed: false},
+		{name: "missing parent", doc: `{"a": 1}`, pointer: "/b/c", want: `{"a": 1}`, removed: false},
+		{name: "array out of range", doc: `{"a": [1]}`, pointer: "/a/1", want: `{"a": [1]}`, removed: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tokens, err := parseJSONPointer(c.pointer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, removed := jsonPointerRemove(mustDecodeJSON(t, c.doc), tokens)
+			if removed != c.removed {
+				t.Errorf("jsonPointerRemove(%s, %s) removed %v, want %v", c.doc, c.pointer, removed, c.removed)
+			}
+			if !reflect.DeepEqual(got, mustDecodeJSON(t, c.want)) {
+				t.Errorf("jsonPointerRemove(%s, %s) = %s, want %s", c.doc, c.pointer, jsonString(got), c.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatchDocument(t *testing.T) {
+	cases := []struct {
+		name   string
+		config map[string]interface{}
+		doc    []byte
+		want   string
+		err    bool
+	}{
+		{
+			name:   "path",
+			config: map[string]interface{}{"path": "/features/x", "value_json": `true`},
+			doc:    []byte(`{"features": {"y": false}}`),
+			want:   `{"features": {"x": true, "y": false}}`,
+		},
+		{
+			name:   "patch",
+			config: map[string]interface{}{"patch": `[{"op": "replace", "path": "/a", "value": 2}]`},
+			doc:    []byte(`{"a": 1}`),
+			want:   `{"a": 2}`,
+		},
+		{
+			name:   "merge patch",
+			config: map[string]interface{}{"merge_patch": `{"a": null, "b": {"c": 1}}`},
+			doc:    []byte(`{"a": 1, "b": {"d": 2}}`),
+			want:   `{"b": {"c": 1, "d": 2}}`,
+		},
+		{
+			name:   "missing key created",
+			config: map[string]interface{}{"path": "/a", "value_json": `1`},
+			want:   `{"a": 1}`,
+		},
+		{
+			name:   "missing key refused",
+			config: map[string]interface{}{"path": "/a", "value_json": `1`, "create_missing": false},
+			err:    true,
+		},
+		{
+			name:   "not a JSON document",
+			config: map[string]interface{}{"path": "/a", "value_json": `1`},
+			doc:    []byte(`a: 1`),
+			err:    true,
+		},
+		{
+			name:   "failing patch",
+			config: map[string]interface{}{"patch": `[{"op": "test", "path": "/a", "value": 2}]`},
+			doc:    []byte(`{"a": 1}`),
+			err:    true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.config["key"] = "/app/config"
+			d := schema.TestResourceDataRaw(t, resourceKeyJSONPatch().Schema, c.config)
+
+			got, err := jsonPatchDocument(d, c.doc)
+			if c.err {
+				if err == nil {
+					t.Fatalf("jsonPatchDocument() = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(mustDecodeJSON(t, string(got)), mustDecodeJSON(t, c.want)) {
+				t.Errorf("jsonPatchDocument() = %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatchDocumentUnchanged(t *testing.T) {
+	// a patch which changes nothing keeps the document as it is written
+	doc := []byte("{\n  \"a\": 1\n}")
+	d := schema.TestResourceDataRaw(t, resourceKeyJSONPatch().Schema, map[string]interface{}{
+		"key":        "/app/config",
+		"path":       "/a",
+		"value_json": `1`,
+	})
+
+	got, err := jsonPatchDocument(d, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(doc) {
+		t.Errorf("jsonPatchDocument() = %s, want %s", got, doc)
+	}
+	if !json.Valid(got) {
+		t.Errorf("jsonPatchDocument() = %s, not a JSON document", got)
+	}
+}
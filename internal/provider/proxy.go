@@ -0,0 +1,174 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/proxy"
+)
+
+// proxySchemes are the schemes proxy_url accepts.
+var proxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// endpointSchemes are the schemes an endpoint may start with. An endpoint
+// without scheme is a host:port.
+var endpointSchemes = []string{"http", "https", "unix", "unixs"}
+
+// validateProxyURL checks proxy_url is a URL using one of proxySchemes.
+func validateProxyURL(val interface{}, key string) (warns []string, errs []error) {
+	if val.(string) == "" {
+		return
+	}
+	u, err := url.Parse(val.(string))
+	if err != nil || !contains(proxySchemes, u.Scheme) || u.Host == "" {
+		errs = append(errs, fmt.Errorf("%q must be a URL such as \"socks5://bastion:1080\" using one of the schemes %v, got: %v", key, proxySchemes, val))
+	}
+	return
+}
+
+// validateEndpoint checks endpoint is a host:port, or a URL using one of
+// endpointSchemes, such as unix:///run/etcd.sock.
+func validateEndpoint(endpoint string) error {
+	if i := strings.Index(endpoint, "://"); i >= 0 {
+		if !contains(endpointSchemes, endpoint[:i]) {
+			return fmt.Errorf("etcd endpoint %q must use one of the schemes %v", endpoint, endpointSchemes)
+		}
+		return nil
+	}
+	if strings.HasPrefix(endpoint, "unix:") || strings.HasPrefix(endpoint, "unixs:") {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(endpoint); err != nil {
+		return fmt.Errorf("etcd endpoint %q must be a host:port or a URL such as https://etcd-0:2379 or unix:///run/etcd.sock", endpoint)
+	}
+	return nil
+}
+
+// proxyDialer returns the gRPC dialer reaching the etcd members through the
+// HTTP CONNECT or SOCKS5 proxy of proxyURL. Unix socket endpoints are dialed
+// directly.
+func proxyDialer(proxyURL string) (func(context.Context, string) (net.Conn, error), error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed parsing proxy_url")
+	}
+
+	var dial func(ctx context.Context, addr string) (net.Conn, error)
+	switch u.Scheme {
+	case "http", "https":
+		dial = func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialConnect(ctx, u, addr)
+		}
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(u, &net.Dialer{})
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed configuring the SOCKS5 proxy")
+		}
+		dial = func(ctx context.Context, addr string) (net.Conn, error) {
+			return d.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+		}
+	default:
+		return nil, fmt.Errorf("proxy_url must use one of the schemes %v, got: %q", proxySchemes, u.Scheme)
+	}
+
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		if path, ok := unixSocketPath(addr); ok {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		}
+		return dial(ctx, addr)
+	}, nil
+}
+
+// unixSocketPath returns the path of addr, as handed to gRPC dialers, when it
+// is a unix socket.
+func unixSocketPath(addr string) (string, bool) {
+	if strings.HasPrefix(addr, "unix://") {
+		return strings.TrimPrefix(addr, "unix://"), true
+	}
+	if strings.HasPrefix(addr, "unix:") {
+		return strings.TrimPrefix(addr, "unix:"), true
+	}
+	return "", false
+}
+
+// dialConnect opens a tunnel to addr through the HTTP proxy at u with the
+// CONNECT method.
+func dialConnect(ctx context.Context, u *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed connecting to the proxy %s", proxyAddr)
+	}
+	if u.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, errors.Wrapf(err, "Failed connecting to the proxy %s", proxyAddr)
+		}
+		conn = tlsConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "Failed sending CONNECT %s to the proxy", addr)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "Failed reading the answer of the proxy to CONNECT %s", addr)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("the proxy refused CONNECT %s: %s", addr, resp.Status)
+	}
+
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were already read into r.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
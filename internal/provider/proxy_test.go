@@ -0,0 +1,53 @@
+package provider
+
+import "testing"
+
+func TestValidateEndpoint(t *testing.T) {
+	cases := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{endpoint: "localhost:2379", valid: true},
+		{endpoint: "10.0.0.1:2379", valid: true},
+		{endpoint: "[::1]:2379", valid: true},
+		{endpoint: "http://etcd-0:2379", valid: true},
+		{endpoint: "https://etcd-0:2379", valid: true},
+		{endpoint: "unix:///run/etcd.sock", valid: true},
+		{endpoint: "unixs:///run/etcd.sock", valid: true},
+		{endpoint: "unix:etcd.sock", valid: true},
+		{endpoint: "etcd-0", valid: false},
+		{endpoint: "ftp://etcd-0:2379", valid: false},
+		{endpoint: "", valid: false},
+	}
+
+	for _, c := range cases {
+		err := validateEndpoint(c.endpoint)
+		if c.valid && err != nil {
+			t.Errorf("validateEndpoint(%q) = %v, want no error", c.endpoint, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("validateEndpoint(%q) = nil, want an error", c.endpoint)
+		}
+	}
+}
+
+func TestUnixSocketPath(t *testing.T) {
+	cases := []struct {
+		addr string
+		path string
+		ok   bool
+	}{
+		{addr: "unix:///run/etcd.sock", path: "/run/etcd.sock", ok: true},
+		{addr: "unix:etcd.sock", path: "etcd.sock", ok: true},
+		{addr: "unix:/run/etcd.sock", path: "/run/etcd.sock", ok: true},
+		{addr: "localhost:2379", ok: false},
+		{addr: "etcd-0:2379", ok: false},
+	}
+
+	for _, c := range cases {
+		path, ok := unixSocketPath(c.addr)
+		if path != c.path || ok != c.ok {
+			t.Errorf("unixSocketPath(%q) = %q, %v, want %q, %v", c.addr, path, ok, c.path, c.ok)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const defaultPageSize = 1000
+
+var (
+	sortOrders  = []string{"NONE", "ASCEND", "DESCEND"}
+	sortTargets = []string{"KEY", "VERSION", "CREATE", "MOD", "VALUE"}
+)
+
+// rangeQuery describes a read of the keys in [key, rangeEnd).
+type rangeQuery struct {
+	key            string
+	rangeEnd       string
+	pageSize       int64
+	limit          int64
+	sortOrder      string
+	sortTarget     string
+	keysOnly       bool
+	minModRevision int64
+	maxModRevision int64
+	revision       int64
+	readOptions    []clientv3.OpOption
+}
+
+// withRangeFilters adds the arguments shared by the data sources reading a
+// range of keys to s.
+func withRangeFilters(s map[string]*schema.Schema) map[string]*schema.Schema {
+	filters := map[string]*schema.Schema{
+		"limit": {
+			Description:  "Maximum number of entries to return. 0 means no limit.",
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      0,
+			ValidateFunc: validateIntAtLeast(0),
+		},
+		"page_size": {
+			Description: "Number of keys fetched per request while paging through the range. Ranges filtered on mod " +
+				"revisions are read in a single request, etcd reading the whole range for them anyway.",
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      defaultPageSize,
+			ValidateFunc: validateIntAtLeast(1),
+		},
+		"sort_order": {
+			Description:  "Order of the entries: NONE, ASCEND or DESCEND.",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "NONE",
+			ValidateFunc: validateStringIn(sortOrders...),
+		},
+		"sort_target": {
+			Description:  "Field used to sort the entries: KEY, VERSION, CREATE, MOD or VALUE.",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "KEY",
+			ValidateFunc: validateStringIn(sortTargets...),
+		},
+		"keys_only": {
+			Description: "Return only the keys, leaving every value empty.",
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+		},
+		"count_only": {
+			Description: "Return only `key_count`, leaving `entries` empty.",
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+		},
+		"min_mod_revision": {
+			Description:  "Only return keys modified at or after this revision.",
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      0,
+			ValidateFunc: validateIntAtLeast(0),
+		},
+		"max_mod_revision": {
+			Description:  "Only return keys modified at or before this revision.",
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      0,
+			ValidateFunc: validateIntAtLeast(0),
+		},
+		"revision":    revisionSchema(),
+		"consistency": consistencySchema(),
+		"decode":      decodeSchema(),
+	}
+	for k, v := range filters {
+		s[k] = v
+	}
+	return s
+}
+
+// newRangeQuery builds the query for [key, rangeEnd) from the arguments added
+// by withRangeFilters.
+func newRangeQuery(d *schema.ResourceData, cli *apiClient, key, rangeEnd string) rangeQuery {
+	return rangeQuery{
+		key:            key,
+		rangeEnd:       rangeEnd,
+		pageSize:       int64(d.Get("page_size").(int)),
+		limit:          int64(d.Get("limit").(int)),
+		sortOrder:      d.Get("sort_order").(string),
+		sortTarget:     d.Get("sort_target").(string),
+		keysOnly:       d.Get("keys_only").(bool),
+		minModRevision: int64(d.Get("min_mod_revision").(int)),
+		maxModRevision: int64(d.Get("max_mod_revision").(int)),
+		revision:       int64(d.Get("revision").(int)),
+		readOptions:    cli.readOptions(d.Get("consistency").(string)),
+	}
+}
+
+// revisionSchema is the argument selecting the revision read by a data source.
+func revisionSchema() *schema.Schema {
+	return &schema.Schema{
+		Description:  "Revision to read the keys at. 0 reads the latest revision.",
+		Type:         schema.TypeInt,
+		Optional:     true,
+		Default:      0,
+		ValidateFunc: validateIntAtLeast(0),
+	}
+}
+
+// consistencySchema is the argument selecting the consistency of the reads of a
+// data source.
+func consistencySchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Consistency of the reads: \"linearizable\" reads go through the leader, \"serializable\" reads are " +
+			"served by any member and keep working without a leader, but may be stale. Defaults to the provider `consistency`.",
+		Type:         schema.TypeString,
+		Optional:     true,
+		ValidateFunc: validateStringIn(consistencyLinearizable, consistencySerializable),
+	}
+}
+
+// readDiagnostics explains err, a failed read of detail, to the user. Reads at
+// a compacted or future revision get a dedicated diagnostic.
+func readDiagnostics(err error, rev int64, detail string) diag.Diagnostics {
+	switch err {
+	case rpctypes.ErrCompacted:
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Revision has been compacted",
+			Detail: fmt.Sprintf("Revision %d is older than the last compaction of the etcd cluster and cannot be read anymore. "+
+				"Pick a more recent revision, or remove 'revision' to read the latest one.", rev),
+		}}
+	case rpctypes.ErrFutureRev:
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Revision does not exist yet",
+			Detail:   fmt.Sprintf("Revision %d is newer than the current revision of the etcd cluster.", rev),
+		}}
+	}
+	return append(diag.FromErr(err), diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  "Error reading data from etcd",
+		Detail:   detail,
+	})
+}
+
+// filters returns the read options and server side filters of the query.
+func (q rangeQuery) filters() []clientv3.OpOption {
+	opts := append([]clientv3.OpOption(nil), q.readOptions...)
+	if q.keysOnly {
+		opts = append(opts, clientv3.WithKeysOnly())
+	}
+	if q.minModRevision > 0 {
+		opts = append(opts, clientv3.WithMinModRev(q.minModRevision))
+	}
+	if q.maxModRevision > 0 {
+		opts = append(opts, clientv3.WithMaxModRev(q.maxModRevision))
+	}
+	return opts
+}
+
+// sorted reports whether the query needs every key before applying the limit.
+func (q rangeQuery) sorted() bool {
+	return q.sortOrder != "" && q.sortOrder != "NONE" && !(q.sortTarget == "KEY" && q.sortOrder == "ASCEND")
+}
+
+// getRange reads the keys matching q page by page, so a large range never has
+// to fit in a single gRPC message. Ranges filtered on mod revisions are read in
+// a single request, see below. Every page after the first one is read at the
+// revision of the first response, which keeps the result consistent. That
+// revision is returned along with the keys.
+func getRange(ctx context.Context, cli clientv3.KV, q rangeQuery) ([]*mvccpb.KeyValue, int64, error) {
+	var kvs []*mvccpb.KeyValue
+	rev := q.revision
+
+	pageSize := q.pageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if q.minModRevision > 0 || q.maxModRevision > 0 {
+		// etcd ignores the limit of a request filtering on revisions: it reads
+		// the whole rest of the range and truncates the filtered result, so
+		// paging would read the range once per page. Read it at once instead.
+		pageSize = 0
+		if !q.sorted() && q.limit > 0 {
+			pageSize = q.limit
+		}
+	}
+	key := q.key
+	if key == "" {
+		key = "\x00"
+	}
+
+	for {
+		opts := append(q.filters(), clientv3.WithRange(q.rangeEnd), clientv3.WithLimit(pageSize))
+		if rev > 0 {
+			opts = append(opts, clientv3.WithRev(rev))
+		}
+		resp, err := cli.Get(ctx, key, opts...)
+		if err != nil {
+			return nil, 0, err
+		}
+		if rev == 0 {
+			rev = resp.Header.Revision
+		}
+		kvs = append(kvs, resp.Kvs...)
+
+		if !q.sorted() && q.limit > 0 && int64(len(kvs)) >= q.limit {
+			return kvs[:q.limit], rev, nil
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+
+	if q.sorted() {
+		sortKeyValues(kvs, q.sortTarget, q.sortOrder)
+	}
+	if q.limit > 0 && int64(len(kvs)) > q.limit {
+		kvs = kvs[:q.limit]
+	}
+
+	return kvs, rev, nil
+}
+
+// countRange returns the number of keys matching q without reading the values,
+// and the revision they were counted at: the revision of q, or the current
+// revision of the cluster when q has none. etcd sets the revision of every
+// response header to its current revision, even for reads at an older one.
+func countRange(ctx context.Context, cli clientv3.KV, q rangeQuery) (int64, int64, error) {
+	if q.minModRevision == 0 && q.maxModRevision == 0 {
+		key := q.key
+		if key == "" {
+			key = "\x00"
+		}
+		opts := append(q.filters(), clientv3.WithRange(q.rangeEnd), clientv3.WithCountOnly(), clientv3.WithRev(q.revision))
+		resp, err := cli.Get(ctx, key, opts...)
+		if err != nil {
+			return 0, 0, err
+		}
+		if q.revision > 0 {
+			return resp.Count, q.revision, nil
+		}
+		return resp.Count, resp.Header.Revision, nil
+	}
+
+	// etcd counts the keys before applying revision filters, so filtered
+	// ranges have to be walked.
+	q.keysOnly = true
+	q.limit = 0
+	q.sortOrder = ""
+	kvs, rev, err := getRange(ctx, cli, q)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(len(kvs)), rev, nil
+}
+
+func sortKeyValues(kvs []*mvccpb.KeyValue, target, order string) {
+	less := func(a, b *mvccpb.KeyValue) bool {
+		switch target {
+		case "VERSION":
+			return a.Version < b.Version
+		case "CREATE":
+			return a.CreateRevision < b.CreateRevision
+		case "MOD":
+			return a.ModRevision < b.ModRevision
+		case "VALUE":
+			return bytes.Compare(a.Value, b.Value) < 0
+		default:
+			return bytes.Compare(a.Key, b.Key) < 0
+		}
+	}
+	sort.SliceStable(kvs, func(i, j int) bool {
+		if order == "DESCEND" {
+			return less(kvs[j], kvs[i])
+		}
+		return less(kvs[i], kvs[j])
+	})
+}
@@ -0,0 +1,351 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func resourceDirectorySync() *schema.Resource {
+	return &schema.Resource{
+		Description: "Copies the files of a local directory tree to keys under a prefix, the path of a file relative " +
+			"to the directory making up its key and its content the value. Later applies only write the files which " +
+			"changed, and `prune` deletes the keys of the files which were removed.",
+		CreateContext: resourceDirectorySyncCreate,
+		ReadContext:   resourceDirectorySyncRead,
+		UpdateContext: resourceDirectorySyncUpdate,
+		DeleteContext: resourceDirectorySyncDelete,
+		Schema: map[string]*schema.Schema{
+			"cluster": resourceClusterSchema(),
+			"source_dir": {
+				Description:  "Path of the local directory to copy.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateNotEmpty,
+			},
+			"prefix": {
+				Description: "Prefix the paths of the files are appended to, usually ending with \"/\". Changing it " +
+					"moves the keys of the files copied to the new prefix, instead of copying the files again.",
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"include": {
+				Description: "Glob patterns of the paths, relative to `source_dir`, of the files to copy. \"**\" " +
+					"matches any number of directories. Defaults to every file.",
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateGlob,
+				},
+			},
+			"exclude": {
+				Description: "Glob patterns of the paths, relative to `source_dir`, of the files not to copy, even " +
+					"when they match `include`.",
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateGlob,
+				},
+			},
+			"prune": {
+				Description: "Delete the keys of the files which were copied by an earlier apply and are no longer " +
+					"found. Keys under `prefix` which were never copied from a file are left alone.",
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"delete_on_destroy": {
+				Description: "Delete the keys of the copied files when the resource is destroyed. By default they " +
+					"are left in place.",
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"files": {
+				Description: "SHA-256 of the content of every file copied, hex encoded, indexed by path relative to " +
+					"`source_dir`.",
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(defaultTimeout),
+		},
+		CustomizeDiff: resourceDirectorySyncCustomizeDiff,
+	}
+}
+
+// directorySyncFiles reads the files of the source directory of d matching its
+// include and exclude patterns.
+func directorySyncFiles(d clusterGetter) (map[string][]byte, error) {
+	include := stringList(d.Get("include").([]interface{}))
+	if len(include) == 0 {
+		include = []string{"**"}
+	}
+	exclude := stringList(d.Get("exclude").([]interface{}))
+
+	dir := d.Get("source_dir").(string)
+	files, err := readSourceDir(dir, include, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading directory %q: %v", dir, err)
+	}
+	return files, nil
+}
+
+// directorySyncHashes returns the files output of files.
+func directorySyncHashes(files map[string][]byte) map[string]string {
+	hashes := make(map[string]string, len(files))
+	for p, content := range files {
+		hashes[p] = contentHash(content)
+	}
+	return hashes
+}
+
+// directorySyncState returns the files output of d, the files copied by the
+// last apply.
+func directorySyncState(d clusterGetter) map[string]string {
+	hashes := make(map[string]string)
+	for p, hash := range d.Get("files").(map[string]interface{}) {
+		hashes[p] = hash.(string)
+	}
+	return hashes
+}
+
+func resourceDirectorySyncCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if meta == nil {
+		return nil
+	}
+	for _, k := range []string{"source_dir", "prefix", "include", "exclude"} {
+		if !d.NewValueKnown(k) {
+			return d.SetNewComputed("files")
+		}
+	}
+
+	// the files or the keys changed since the last apply, copy them again
+	files, err := directorySyncFiles(d)
+	if err != nil {
+		return err
+	}
+	hashes := directorySyncHashes(files)
+	if !reflect.DeepEqual(hashes, directorySyncState(d)) {
+		if err := d.SetNew("files", hashes); err != nil {
+			return err
+		}
+	}
+
+	if !diffHasChanges(d) {
+		return nil
+	}
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return err
+	}
+	prefix := d.Get("prefix").(string)
+	return cli.guardrails.checkRange(prefix, clientv3.GetPrefixRangeEnd(prefix))
+}
+
+func resourceDirectorySyncCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := resourceDirectorySyncWrite(ctx, d, meta, nil); diags.HasError() {
+		return diags
+	}
+
+	d.SetId(uuidGenerator())
+
+	return resourceDirectorySyncRead(ctx, d, meta)
+}
+
+func resourceDirectorySyncUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	oldFiles, _ := d.GetChange("files")
+	copied := make(map[string]string)
+	for p, hash := range oldFiles.(map[string]interface{}) {
+		copied[p] = hash.(string)
+	}
+
+	if d.HasChange("prefix") {
+		oldPrefix, newPrefix := d.GetChange("prefix")
+		if diags := resourceDirectorySyncMove(ctx, d, meta, oldPrefix.(string), newPrefix.(string), copied); diags.HasError() {
+			return diags
+		}
+	}
+
+	if diags := resourceDirectorySyncWrite(ctx, d, meta, copied); diags.HasError() {
+		return diags
+	}
+
+	return resourceDirectorySyncRead(ctx, d, meta)
+}
+
+// resourceDirectorySyncMove moves the keys of the files of copied from the
+// prefix from to the prefix to. The other keys under from were not copied from
+// a file and are left alone. Every key is written before the old keys are
+// deleted, and the old keys which are also new keys, when the prefixes
+// overlap, are not deleted at all.
+func resourceDirectorySyncMove(ctx context.Context, d *schema.ResourceData, meta interface{}, from, to string, copied map[string]string) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	kvs, _, err := getRange(ctx, cli, rangeQuery{key: from, rangeEnd: clientv3.GetPrefixRangeEnd(from)})
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading the keys under %s", from)))
+	}
+	moved := make(map[string]bool, len(copied))
+	var puts, deletes []clientv3.Op
+	for _, kv := range kvs {
+		p := strings.TrimPrefix(string(kv.Key), from)
+		if _, ok := copied[p]; !ok {
+			continue
+		}
+		if err := cli.guardrails.checkWrite(to + p); err != nil {
+			return diag.FromErr(err)
+		}
+		puts = append(puts, clientv3.OpPut(to+p, string(kv.Value)))
+		moved[to+p] = true
+	}
+	for _, kv := range kvs {
+		key := string(kv.Key)
+		if _, ok := copied[strings.TrimPrefix(key, from)]; !ok || moved[key] {
+			continue
+		}
+		if err := cli.guardrails.checkDelete(key); err != nil {
+			return diag.FromErr(err)
+		}
+		deletes = append(deletes, clientv3.OpDelete(key))
+	}
+
+	if err := commitOps(ctx, cli, append(puts, deletes...)); err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error moving the keys under %s to %s", from, to)))
+	}
+	tflog.Debug(ctx, fmt.Sprintf("Moved %d keys from %s to %s", len(puts), from, to))
+	return nil
+}
+
+// resourceDirectorySyncWrite writes the files which differ from their keys, and
+// with prune deletes the keys of the files of copied which are gone.
+func resourceDirectorySyncWrite(ctx context.Context, d *schema.ResourceData, meta interface{}, copied map[string]string) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	prefix := d.Get("prefix").(string)
+	if err := cli.guardrails.checkRange(prefix, clientv3.GetPrefixRangeEnd(prefix)); err != nil {
+		return diag.FromErr(err)
+	}
+	files, err := directorySyncFiles(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	current, _, err := getRange(ctx, cli, rangeQuery{key: prefix, rangeEnd: clientv3.GetPrefixRangeEnd(prefix)})
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading the keys under %s", prefix)))
+	}
+	existing := make(map[string]string, len(current))
+	for _, kv := range current {
+		existing[string(kv.Key)] = string(kv.Value)
+	}
+
+	var ops []clientv3.Op
+	for p, content := range files {
+		key := prefix + p
+		if v, ok := existing[key]; !ok || v != string(content) {
+			if err := cli.guardrails.checkWrite(key); err != nil {
+				return diag.FromErr(err)
+			}
+			ops = append(ops, clientv3.OpPut(key, string(content)))
+		}
+	}
+	if d.Get("prune").(bool) {
+		for p := range copied {
+			key := prefix + p
+			if _, ok := files[p]; ok {
+				continue
+			}
+			if _, ok := existing[key]; !ok {
+				continue
+			}
+			if err := cli.guardrails.checkDelete(key); err != nil {
+				return diag.FromErr(err)
+			}
+			ops = append(ops, clientv3.OpDelete(key))
+		}
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Copying %d files of %s to %s with %d changes", len(files), d.Get("source_dir"), prefix, len(ops)))
+
+	if err := commitOps(ctx, cli, ops); err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error writing the keys under %s", prefix)))
+	}
+
+	if err := d.Set("files", directorySyncHashes(files)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// resourceDirectorySyncRead sets files to the hashes of the values of the keys
+// of the files copied, so a key changed or deleted outside of Terraform shows
+// as a change of its file.
+func resourceDirectorySyncRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	prefix := d.Get("prefix").(string)
+	kvs, _, err := getRange(ctx, cli, rangeQuery{key: prefix, rangeEnd: clientv3.GetPrefixRangeEnd(prefix)})
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading the keys under %s", prefix)))
+	}
+
+	copied := directorySyncState(d)
+	hashes := make(map[string]string, len(copied))
+	for _, kv := range kvs {
+		p := strings.TrimPrefix(string(kv.Key), prefix)
+		if _, ok := copied[p]; ok {
+			hashes[p] = contentHash(kv.Value)
+		}
+	}
+	if err := d.Set("files", hashes); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourceDirectorySyncDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if !d.Get("delete_on_destroy").(bool) {
+		return nil
+	}
+
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	prefix := d.Get("prefix").(string)
+	copied := directorySyncState(d)
+	ops := make([]clientv3.Op, 0, len(copied))
+	for p := range copied {
+		key := prefix + p
+		if err := cli.guardrails.checkDelete(key); err != nil {
+			return diag.FromErr(err)
+		}
+		ops = append(ops, clientv3.OpDelete(key))
+	}
+	if err := commitOps(ctx, cli, ops); err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error deleting the keys under %s", prefix)))
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var documentAttributes = []string{"document_json", "document_yaml"}
+
+func resourceDocument() *schema.Resource {
+	return &schema.Resource{
+		Description: "Writes a nested JSON or YAML document as a tree of keys under a prefix, one key per value, the " +
+			"attribute names joined with `delimiter` making up the keys. The keys under the prefix which are not in " +
+			"the document are deleted.",
+		CreateContext: resourceDocumentCreate,
+		ReadContext:   resourceDocumentRead,
+		UpdateContext: resourceDocumentUpdate,
+		DeleteContext: resourceDocumentDelete,
+		Schema: map[string]*schema.Schema{
+			"cluster": resourceClusterSchema(),
+			"prefix": {
+				Description: "Prefix of the keys, usually ending with `delimiter`. The resource owns every key under it. " +
+					"Changing it moves the keys to the new prefix in transactions, instead of deleting and writing them again.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateNotEmpty,
+			},
+			"delimiter": {
+				Description:  "Delimiter joining the attribute names of the document into keys.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "/",
+				ValidateFunc: validateNotEmpty,
+			},
+			"document_json": {
+				Description: "Document written, a JSON object. Use `jsonencode()` to write a Terraform object. " +
+					"Only the changes of the keys written show in the plan.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validateValue(valueFormatJSON),
+				DiffSuppressFunc: suppressEquivalentDocument(valueFormatJSON),
+				ExactlyOneOf:     documentAttributes,
+			},
+			"document_yaml": {
+				Description:      "Document written, a YAML mapping. Only the changes of the keys written show in the plan.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validateValue(valueFormatYAML),
+				DiffSuppressFunc: suppressEquivalentDocument(valueFormatYAML),
+				ExactlyOneOf:     documentAttributes,
+			},
+			"entries": {
+				Description: "Keys written, with their values.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(defaultTimeout),
+		},
+		CustomizeDiff: resourceDocumentCustomizeDiff,
+	}
+}
+
+// documentAttribute returns the attribute of d holding the document, along
+// with its format.
+func documentAttribute(d clusterGetter) (string, string) {
+	if d.Get("document_yaml").(string) != "" {
+		return "document_yaml", valueFormatYAML
+	}
+	return "document_json", valueFormatJSON
+}
+
+// documentEntries returns the keys of the document of d, with their values.
+func documentEntries(d clusterGetter) (map[string]string, error) {
+	attr, format := documentAttribute(d)
+	doc, err := decodeValue(format, d.Get(attr).(string))
+	if err != nil {
+		return nil, fmt.Errorf("%q must be a valid %s document: %v", attr, format, err)
+	}
+	tree, err := flattenTree(doc, d.Get("delimiter").(string))
+	if err != nil {
+		return nil, fmt.Errorf("cannot write %q as keys: %v", attr, err)
+	}
+
+	prefix := d.Get("prefix").(string)
+	entries := make(map[string]string, len(tree))
+	for k, v := range tree {
+		entries[prefix+k] = v
+	}
+	return entries, nil
+}
+
+// suppressEquivalentDocument ignores the changes of a document which do not
+// change the keys it is written as, such as its formatting or a number becoming
+// a string.
+func suppressEquivalentDocument(format string) schema.SchemaDiffSuppressFunc {
+	return func(k, old, new string, d *schema.ResourceData) bool {
+		delimiter := d.Get("delimiter").(string)
+		var trees []map[string]string
+		for _, v := range []string{old, new} {
+			doc, err := decodeValue(format, v)
+			if err != nil {
+				return false
+			}
+			tree, err := flattenTree(doc, delimiter)
+			if err != nil {
+				return false
+			}
+			trees = append(trees, tree)
+		}
+		return reflect.DeepEqual(trees[0], trees[1])
+	}
+}
+
+func resourceDocumentCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if meta == nil || !diffHasChanges(d) {
+		return nil
+	}
+	for _, k := range append([]string{"prefix", "delimiter"}, documentAttributes...) {
+		if !d.NewValueKnown(k) {
+			return d.SetNewComputed("entries")
+		}
+	}
+
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return err
+	}
+	prefix := d.Get("prefix").(string)
+	if err := cli.guardrails.checkRange(prefix, clientv3.GetPrefixRangeEnd(prefix)); err != nil {
+		return err
+	}
+
+	entries, err := documentEntries(d)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]string)
+	for k, v := range d.Get("entries").(map[string]interface{}) {
+		current[k] = v.(string)
+	}
+	if reflect.DeepEqual(entries, current) {
+		return nil
+	}
+	return d.SetNew("entries", entries)
+}
+
+func resourceDocumentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := resourceDocumentWrite(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+
+	d.SetId(uuidGenerator())
+
+	return resourceDocumentRead(ctx, d, meta)
+}
+
+func resourceDocumentUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.HasChange("prefix") {
+		cli, err := clientFor(d, meta)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		oldPrefix, newPrefix := d.GetChange("prefix")
+		from, to := oldPrefix.(string), newPrefix.(string)
+		if err := cli.guardrails.checkRange(from, clientv3.GetPrefixRangeEnd(from)); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := cli.guardrails.checkRange(to, clientv3.GetPrefixRangeEnd(to)); err != nil {
+			return diag.FromErr(err)
+		}
+
+		moved, err := movePrefix(ctx, cli, from, to, cli.guardrails.checkDelete)
+		if err != nil {
+			return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error moving the keys under %s to %s", from, to)))
+		}
+		tflog.Debug(ctx, fmt.Sprintf("Moved %d keys from %s to %s", moved, from, to))
+	}
+
+	if diags := resourceDocumentWrite(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+
+	return resourceDocumentRead(ctx, d, meta)
+}
+
+// resourceDocumentWrite writes the keys of the document which differ from the
+// ones under the prefix, and deletes the keys under the prefix which are not
+// in the document.
+func resourceDocumentWrite(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	prefix := d.Get("prefix").(string)
+	if err := cli.guardrails.checkRange(prefix, clientv3.GetPrefixRangeEnd(prefix)); err != nil {
+		return diag.FromErr(err)
+	}
+	entries, err := documentEntries(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	current, _, err := getRange(ctx, cli, rangeQuery{key: prefix, rangeEnd: clientv3.GetPrefixRangeEnd(prefix)})
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading the keys under %s", prefix)))
+	}
+	existing := make(map[string]string, len(current))
+	for _, kv := range current {
+		existing[string(kv.Key)] = string(kv.Value)
+	}
+
+	var ops []clientv3.Op
+	for key, value := range entries {
+		if v, ok := existing[key]; !ok || v != value {
+			if err := cli.guardrails.checkWrite(key); err != nil {
+				return diag.FromErr(err)
+			}
+			ops = append(ops, clientv3.OpPut(key, value))
+		}
+	}
+	for key := range existing {
+		if _, ok := entries[key]; !ok {
+			if err := cli.guardrails.checkDelete(key); err != nil {
+				return diag.FromErr(err)
+			}
+			ops = append(ops, clientv3.OpDelete(key))
+		}
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Writing a document of %d keys under %s with %d changes", len(entries), prefix, len(ops)))
+
+	if err := commitOps(ctx, cli, ops); err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error writing the keys under %s", prefix)))
+	}
+	return nil
+}
+
+func resourceDocumentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	prefix := d.Get("prefix").(string)
+	kvs, _, err := getRange(ctx, cli, rangeQuery{key: prefix, rangeEnd: clientv3.GetPrefixRangeEnd(prefix)})
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading the keys under %s", prefix)))
+	}
+	entries := make(map[string]string, len(kvs))
+	relative := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		entries[string(kv.Key)] = textValue(kv.Value)
+		relative[strings.TrimPrefix(string(kv.Key), prefix)] = textValue(kv.Value)
+	}
+	if err := d.Set("entries", entries); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// keep the document as written in the configuration while the keys match
+	// it, and read the keys back into a document when they do not
+	if desired, err := documentEntries(d); err == nil && reflect.DeepEqual(desired, entries) {
+		return nil
+	}
+	doc, err := json.Marshal(buildTree(relative, d.Get("delimiter").(string)))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	attr, _ := documentAttribute(d)
+	if err := d.Set(attr, string(doc)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourceDocumentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	entries := d.Get("entries").(map[string]interface{})
+	ops := make([]clientv3.Op, 0, len(entries))
+	for key := range entries {
+		if err := cli.guardrails.checkDelete(key); err != nil {
+			return diag.FromErr(err)
+		}
+		ops = append(ops, clientv3.OpDelete(key))
+	}
+	if err := commitOps(ctx, cli, ops); err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error deleting the keys under %s", d.Get("prefix"))))
+	}
+	return nil
+}
@@ -6,14 +6,10 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/pkg/errors"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
-var requestTimeout = 10 * time.Second
-
 func resourceGrantRoleUser() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceGrantRoleUserCreate,
@@ -21,6 +17,7 @@ func resourceGrantRoleUser() *schema.Resource {
 		UpdateContext: resourceGrantRoleUserUpdate,
 		DeleteContext: resourceGrantRoleUserRevoke,
 		Schema: map[string]*schema.Schema{
+			"cluster": resourceClusterSchema(),
 			"user_name": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -31,15 +28,23 @@ func resourceGrantRoleUser() *schema.Resource {
 			},
 		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateCluster,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(defaultTimeout),
 		},
+		CustomizeDiff: customizeDiffReadOnly,
 	}
 }
 
-func resourceGrantRoleUserCreate(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	cli := meta.(*clientv3.Client)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	defer cancel()
+func resourceGrantRoleUserCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := cli.guardrails.checkReadOnly(); err != nil {
+		return diag.FromErr(err)
+	}
 
 	user := d.Get("user_name").(string)
 	role := d.Get("role").(string)
@@ -58,7 +63,7 @@ func resourceGrantRoleUserCreate(_ context.Context, d *schema.ResourceData, meta
 		return diag.FromErr(errors.Wrap(errRoleGet, fmt.Sprintf("The role %s doesn't exist, please create it first.", role)))
 	}
 
-	_, err := cli.UserGrantRole(ctx, user, role)
+	_, err = cli.UserGrantRole(ctx, user, role)
 
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed granting role %s to user %s", role, user)))
@@ -69,10 +74,11 @@ func resourceGrantRoleUserCreate(_ context.Context, d *schema.ResourceData, meta
 	return resourceGrantRoleUserRead(ctx, d, meta)
 }
 
-func resourceGrantRoleUserRead(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	cli := meta.(*clientv3.Client)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	defer cancel()
+func resourceGrantRoleUserRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	user := d.Get("user_name").(string)
 	role := d.Get("role").(string)
 
@@ -93,15 +99,19 @@ func resourceGrantRoleUserRead(_ context.Context, d *schema.ResourceData, meta i
 }
 
 func resourceGrantRoleUserUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	cli := meta.(*clientv3.Client)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := cli.guardrails.checkReadOnly(); err != nil {
+		return diag.FromErr(err)
+	}
 	tflog.Debug(ctx, "some test message for Update function")
 
 	user := d.Get("user_name").(string)
 	role := d.Get("role").(string)
 
 	_, errUserGet := cli.UserGet(ctx, user)
-	defer cancel()
 	if errUserGet != nil {
 		return diag.FromErr(errors.Wrap(errUserGet, fmt.Sprintf("The user %s doesn't exist, please create it first.", user)))
 	}
@@ -111,7 +121,7 @@ func resourceGrantRoleUserUpdate(ctx context.Context, d *schema.ResourceData, me
 		return diag.FromErr(errors.Wrap(errUserGet, fmt.Sprintf("The role %s doesn't exist, please create it first.", role)))
 	}
 
-	_, err := cli.UserGrantRole(ctx, user, role)
+	_, err = cli.UserGrantRole(ctx, user, role)
 
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed granting role: %v to user: %v", role, user)))
@@ -122,16 +132,25 @@ func resourceGrantRoleUserUpdate(ctx context.Context, d *schema.ResourceData, me
 	return resourceGrantRoleUserRead(ctx, d, meta)
 }
 
-func resourceGrantRoleUserRevoke(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	cli := m.(*clientv3.Client)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+func resourceGrantRoleUserRevoke(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := cli.guardrails.checkReadOnly(); err != nil {
+		return diag.FromErr(err)
+	}
 	tflog.Info(ctx, "some test message for Revoke function")
 
 	user := d.Get("user_name").(string)
 	role := d.Get("role").(string)
+	if user == "root" && role == "root" {
+		if diags := cli.checkRootChange(ctx, "revoke the root role from the root user"); diags.HasError() {
+			return diags
+		}
+	}
 
 	_, errRevokeRole := cli.UserRevokeRole(ctx, user, role)
-	defer cancel()
 	if errRevokeRole != nil {
 		return diag.FromErr(errors.Wrap(errRevokeRole, fmt.Sprintf("Failed revoke role: %v from user: %v", role, user)))
 	}
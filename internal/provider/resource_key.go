@@ -2,14 +2,15 @@ package provider
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
-	"time"
+	"os"
+	"unicode/utf8"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/pkg/errors"
-	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 func resourceKey() *schema.Resource {
@@ -19,32 +20,116 @@ func resourceKey() *schema.Resource {
 		UpdateContext: resourceKeyUpdate,
 		DeleteContext: resourceKeyDelete,
 		Schema: map[string]*schema.Schema{
+			"cluster": resourceClusterSchema(),
 			"key": {
 				Description: "Etcd key",
 				Type:        schema.TypeString,
 				Optional:    true,
 			},
 			"value": {
-				Description: "Etcd value",
+				Description:   "Etcd value",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"value_json", "value_yaml", "value_base64", "source"},
+			},
+			"value_json": {
+				Description: "Etcd value, a JSON document. Changes which do not alter the document, such as " +
+					"indentation or the order of the attributes, are ignored.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validateValue(valueFormatJSON),
+				DiffSuppressFunc: suppressEquivalentValue(valueFormatJSON),
+				ConflictsWith:    []string{"value", "value_yaml", "value_base64", "source"},
+			},
+			"value_yaml": {
+				Description: "Etcd value, a YAML document. Changes which do not alter the document, such as " +
+					"indentation, comments or the order of the attributes, are ignored.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validateValue(valueFormatYAML),
+				DiffSuppressFunc: suppressEquivalentValue(valueFormatYAML),
+				ConflictsWith:    []string{"value", "value_json", "value_base64", "source"},
+			},
+			"value_base64": {
+				Description: "Etcd value, base64 encoded, for values which are not UTF-8 text. A value read which is " +
+					"not valid UTF-8 is always stored here.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ValidateFunc:  validateBase64,
+				ConflictsWith: []string{"value", "value_json", "value_yaml", "source"},
+			},
+			"source": {
+				Description: "Path of a local file holding the value. Changes of the file are noticed through " +
+					"`source_hash`, its content is not stored in the state.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"value", "value_json", "value_yaml", "value_base64"},
+			},
+			"source_hash": {
+				Description: "SHA-256 of the value, hex encoded, when it is read from `source`.",
 				Type:        schema.TypeString,
-				Optional:    true,
+				Computed:    true,
 			},
 		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateCluster,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(defaultTimeout),
+		},
+		CustomizeDiff: resourceKeyCustomizeDiff,
 	}
 }
 
-func resourceKeyCreate(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-	cli := meta.(*clientv3.Client)
+func resourceKeyCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if meta == nil {
+		return nil
+	}
+
+	// the file or the key changed since the last apply, write the file again
+	if source := d.Get("source").(string); source != "" && d.NewValueKnown("source") {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed reading source %q: %v", source, err)
+		}
+		if hash := contentHash(content); hash != d.Get("source_hash").(string) {
+			if err := d.SetNew("source_hash", hash); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !diffHasChanges(d) || !d.NewValueKnown("key") {
+		return nil
+	}
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return err
+	}
+
+	oldKey, newKey := d.GetChange("key")
+	if d.Id() != "" && oldKey.(string) != newKey.(string) {
+		if err := cli.guardrails.checkDelete(oldKey.(string)); err != nil {
+			return err
+		}
+	}
+	return cli.guardrails.checkWrite(newKey.(string))
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	defer cancel()
+func resourceKeyCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	key := d.Get("key").(string)
-	value := d.Get("value").(string)
+	value, err := keyValue(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := cli.guardrails.checkWrite(key); err != nil {
+		return diag.FromErr(err)
+	}
 	resp, err := cli.Get(ctx, key)
 
 	tflog.Debug(ctx, fmt.Sprintf("cli.Get response: %s, kvs: %s, count: %v", resp.Kvs, resp.Kvs, resp.Count))
@@ -63,12 +148,12 @@ func resourceKeyCreate(_ context.Context, d *schema.ResourceData, meta interface
 	return resourceKeyRead(ctx, d, meta)
 }
 
-func resourceKeyRead(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-	cli := meta.(*clientv3.Client)
+func resourceKeyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	defer cancel()
 	key := d.Get("key").(string)
 	if key == "" {
 		key = d.Id()
@@ -84,10 +169,36 @@ func resourceKeyRead(_ context.Context, d *schema.ResourceData, meta interface{}
 		return diag.Errorf("Etcd returns no answer. It is suppose to have at least one empty value")
 	}
 
+	var diags diag.Diagnostics
 	for _, ev := range resp.Kvs {
 		tflog.Debug(ctx, fmt.Sprintf("here is the resp.kvs %v", resp.Kvs))
-		if err := d.Set("value", string(ev.Value)); err != nil {
-			return diag.FromErr(errors.Wrap(err, "Failed saving data into 'value'."))
+		attr, format := keyValueAttribute(d)
+		if format != valueFormatBase64 && format != valueFormatSource && !utf8.Valid(ev.Value) {
+			// the value cannot be stored as text, switch to value_base64
+			if err := d.Set(attr, ""); err != nil {
+				return diag.FromErr(err)
+			}
+			attr, format = "value_base64", valueFormatBase64
+		}
+		if format == valueFormatJSON || format == valueFormatYAML {
+			if _, err := decodeValue(format, string(ev.Value)); err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Warning,
+					Summary:  fmt.Sprintf("Value of key %s is not a valid %s document", key, format),
+					Detail:   fmt.Sprintf("The value was changed outside of Terraform and does not parse: %v. The next apply overwrites it.", err),
+				})
+			}
+		}
+		value := string(ev.Value)
+		switch format {
+		case valueFormatBase64:
+			value = base64.StdEncoding.EncodeToString(ev.Value)
+		case valueFormatSource:
+			// only the hash of the file is kept in the state
+			value = contentHash(ev.Value)
+		}
+		if err := d.Set(attr, value); err != nil {
+			return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed saving data into '%s'.", attr)))
 		}
 		err := d.Set("key", ev.Key)
 		if err != nil {
@@ -97,19 +208,88 @@ func resourceKeyRead(_ context.Context, d *schema.ResourceData, meta interface{}
 
 	d.SetId(uuidGenerator())
 
-	return nil
+	return diags
 }
 
-func resourceKeyUpdate(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-	if d.HasChange("value") {
+// keyValue returns the value of the key, whichever of value, value_json,
+// value_yaml, value_base64 or the source file holds it.
+func keyValue(d *schema.ResourceData) (string, error) {
+	attr, format := keyValueAttribute(d)
+	switch format {
+	case valueFormatBase64:
+		// validated by the schema
+		b, _ := base64.StdEncoding.DecodeString(d.Get(attr).(string))
+		return string(b), nil
+	case valueFormatSource:
+		source := d.Get("source").(string)
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return "", errors.Wrap(err, fmt.Sprintf("Failed reading source %s", source))
+		}
+		return string(content), nil
+	}
+	return d.Get(attr).(string), nil
+}
+
+// keyValueAttribute returns the attribute holding the value of the key, along
+// with the format of its content, empty for plain values. The value read from
+// a source file is kept as its hash in source_hash.
+func keyValueAttribute(d *schema.ResourceData) (string, string) {
+	if d.Get("source").(string) != "" {
+		return "source_hash", valueFormatSource
+	}
+	for _, format := range append(valueFormats, valueFormatBase64) {
+		if attr := "value_" + format; d.Get(attr).(string) != "" {
+			return attr, format
+		}
+	}
+	return "value", ""
+}
+
+func resourceKeyUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.HasChange("key") {
+		cli, err := clientFor(d, meta)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		oldKey, newKey := d.GetChange("key")
+		if err := cli.guardrails.checkDelete(oldKey.(string)); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := cli.guardrails.checkWrite(newKey.(string)); err != nil {
+			return diag.FromErr(err)
+		}
+
+		value, err := keyValue(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		// put the new key and delete the old one in a single transaction, so
+		// readers never miss the key
+		if err := moveKey(ctx, cli, oldKey.(string), newKey.(string), value); err != nil {
+			return diag.FromErr(errors.Wrap(err, "Error moving key in etcd server"))
+		}
+
+		return resourceKeyRead(ctx, d, meta)
+	}
+
+	if d.HasChanges("value", "value_json", "value_yaml", "value_base64", "source", "source_hash") {
 
-		cli := meta.(*clientv3.Client)
+		cli, err := clientFor(d, meta)
+		if err != nil {
+			return diag.FromErr(err)
+		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-		defer cancel()
 		key := d.Get("key").(string)
-		value := d.Get("value").(string)
+		value, err := keyValue(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if err := cli.guardrails.checkWrite(key); err != nil {
+			return diag.FromErr(err)
+		}
 
 		resp, err := cli.Get(ctx, key)
 
@@ -130,13 +310,16 @@ func resourceKeyUpdate(_ context.Context, d *schema.ResourceData, meta interface
 	return nil
 }
 
-func resourceKeyDelete(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-	cli := meta.(*clientv3.Client)
+func resourceKeyDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	defer cancel()
 	key := d.Get("key").(string)
+	if err := cli.guardrails.checkDelete(key); err != nil {
+		return diag.FromErr(err)
+	}
 
 	_, errDelete := cli.Delete(ctx, key)
 
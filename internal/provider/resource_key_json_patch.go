@@ -0,0 +1,308 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+)
+
+var jsonPatchModes = []string{"path", "patch", "merge_patch"}
+
+func resourceKeyJSONPatch() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manages a part of the JSON document held by a key, leaving the rest of the document to others: " +
+			"the value at a JSON pointer `path`, or the changes of an RFC 6902 `patch` or of an RFC 7386 `merge_patch`. " +
+			"The document is read, changed and written back in a transaction which fails when the key was written " +
+			"meanwhile, and is then tried again.",
+		CreateContext: resourceKeyJSONPatchCreate,
+		ReadContext:   resourceKeyJSONPatchRead,
+		UpdateContext: resourceKeyJSONPatchUpdate,
+		DeleteContext: resourceKeyJSONPatchDelete,
+		Schema: map[string]*schema.Schema{
+			"cluster": resourceClusterSchema(),
+			"key": {
+				Description:  "Key holding the JSON document.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateNotEmpty,
+			},
+			"path": {
+				Description: "JSON pointer (RFC 6901) of the part of the document set to `value_json`, such as " +
+					"\"/features/x\". The missing objects along the path are created. Destroying the resource removes " +
+					"the part again.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validateJSONPointer,
+				ExactlyOneOf: jsonPatchModes,
+				RequiredWith: []string{"value_json"},
+			},
+			"value_json": {
+				Description:      "JSON value set at `path`.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validateValue(valueFormatJSON),
+				DiffSuppressFunc: suppressEquivalentValue(valueFormatJSON),
+				RequiredWith:     []string{"path"},
+			},
+			"patch": {
+				Description: "JSON patch (RFC 6902) applied to the document, a JSON array of operations. It is applied " +
+					"again whenever it would change the document, so its operations should not keep changing it, like " +
+					"an `add` to the end of an array does. Destroying the resource leaves the document as it is.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validateJSONPatch,
+				DiffSuppressFunc: suppressEquivalentValue(valueFormatJSON),
+				ExactlyOneOf:     jsonPatchModes,
+			},
+			"merge_patch": {
+				Description: "JSON merge patch (RFC 7386) applied to the document, a JSON object whose attributes replace " +
+					"the ones of the document, null removing them. Destroying the resource leaves the document as it is.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validateValue(valueFormatJSON),
+				DiffSuppressFunc: suppressEquivalentValue(valueFormatJSON),
+				ExactlyOneOf:     jsonPatchModes,
+			},
+			"create_missing": {
+				Description: "Start from an empty document `{}` when the key does not exist. When false, a missing key is an error.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+			},
+			"in_sync": {
+				Description: "Whether the document holds the changes of `patch` or `merge_patch`. When it does not, the " +
+					"next apply applies them again.",
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(defaultTimeout),
+		},
+		CustomizeDiff: resourceKeyJSONPatchCustomizeDiff,
+	}
+}
+
+func validateJSONPatch(val interface{}, key string) (warns []string, errs []error) {
+	if _, err := jsonpatch.DecodePatch([]byte(val.(string))); err != nil {
+		errs = append(errs, fmt.Errorf("%q must be a JSON patch: %v", key, err))
+	}
+	return
+}
+
+func resourceKeyJSONPatchCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if meta == nil {
+		return nil
+	}
+
+	// the document changed since the last apply, patch it again
+	if d.Id() != "" && !d.Get("in_sync").(bool) {
+		if err := d.SetNew("in_sync", true); err != nil {
+			return err
+		}
+	}
+
+	if !diffHasChanges(d) || !d.NewValueKnown("key") {
+		return nil
+	}
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return err
+	}
+	return cli.guardrails.checkWrite(d.Get("key").(string))
+}
+
+// jsonPatchDocument applies the path, patch or merge_patch of d to the document
+// doc, nil when the key does not exist.
+func jsonPatchDocument(d *schema.ResourceData, doc []byte) ([]byte, error) {
+	key := d.Get("key").(string)
+	if doc == nil {
+		if !d.Get("create_missing").(bool) {
+			return nil, fmt.Errorf("key %q does not exist", key)
+		}
+		doc = []byte("{}")
+	}
+
+	var patched []byte
+	switch {
+	case d.Get("path").(string) != "":
+		tokens, err := parseJSONPointer(d.Get("path").(string))
+		if err != nil {
+			return nil, err
+		}
+		current, err := decodeJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("the value of key %q is not a valid JSON document: %v", key, err)
+		}
+		value, err := decodeJSON([]byte(d.Get("value_json").(string)))
+		if err != nil {
+			return nil, err
+		}
+		updated, err := jsonPointerSet(current, tokens, value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot set %s in the value of key %q: %v", d.Get("path"), key, err)
+		}
+		if patched, err = json.Marshal(updated); err != nil {
+			return nil, err
+		}
+	case d.Get("patch").(string) != "":
+		patch, err := jsonpatch.DecodePatch([]byte(d.Get("patch").(string)))
+		if err != nil {
+			return nil, err
+		}
+		if patched, err = patch.Apply(doc); err != nil {
+			return nil, fmt.Errorf("cannot apply the patch to the value of key %q: %v", key, err)
+		}
+	default:
+		var err error
+		if patched, err = jsonpatch.MergePatch(doc, []byte(d.Get("merge_patch").(string))); err != nil {
+			return nil, fmt.Errorf("cannot apply the merge patch to the value of key %q: %v", key, err)
+		}
+	}
+
+	// keep the document as it is written when nothing changes
+	if jsonpatch.Equal(doc, patched) {
+		return doc, nil
+	}
+	return patched, nil
+}
+
+func resourceKeyJSONPatchCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := resourceKeyJSONPatchApply(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+
+	d.SetId(uuidGenerator())
+
+	return resourceKeyJSONPatchRead(ctx, d, meta)
+}
+
+func resourceKeyJSONPatchUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := resourceKeyJSONPatchApply(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+
+	return resourceKeyJSONPatchRead(ctx, d, meta)
+}
+
+// resourceKeyJSONPatchApply writes the changes of d to the document of the key.
+func resourceKeyJSONPatchApply(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	key := d.Get("key").(string)
+	if err := cli.guardrails.checkWrite(key); err != nil {
+		return diag.FromErr(err)
+	}
+
+	rev, err := updateKey(ctx, cli, key, func(value []byte) ([]byte, error) {
+		return jsonPatchDocument(d, value)
+	})
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error patching the value of key %s", key)))
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Patched the value of key %s at revision %d", key, rev))
+	return nil
+}
+
+func resourceKeyJSONPatchRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	key := d.Get("key").(string)
+	resp, err := cli.Get(ctx, key)
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading key %s", key)))
+	}
+	if len(resp.Kvs) == 0 {
+		tflog.Warn(ctx, fmt.Sprintf("Key %s does not exist anymore, removing it from the state", key))
+		d.SetId("")
+		return nil
+	}
+	doc := resp.Kvs[0].Value
+
+	if path := d.Get("path").(string); path != "" {
+		tokens, err := parseJSONPointer(path)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		current, err := decodeJSON(doc)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("the value of key %q is not a valid JSON document: %v", key, err))
+		}
+		value, ok := jsonPointerGet(current, tokens)
+		if !ok {
+			tflog.Warn(ctx, fmt.Sprintf("%s does not exist anymore in the value of key %s, removing it from the state", path, key))
+			d.SetId("")
+			return nil
+		}
+		if err := d.Set("value_json", jsonString(value)); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := d.Set("in_sync", true); err != nil {
+			return diag.FromErr(err)
+		}
+		return nil
+	}
+
+	// a patch which no longer applies is reported by the next apply
+	patched, err := jsonPatchDocument(d, doc)
+	if err := d.Set("in_sync", err == nil && string(patched) == string(doc)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourceKeyJSONPatchDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	path := d.Get("path").(string)
+	if path == "" {
+		return nil
+	}
+
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	key := d.Get("key").(string)
+	if err := cli.guardrails.checkWrite(key); err != nil {
+		return diag.FromErr(err)
+	}
+	tokens, err := parseJSONPointer(path)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	_, err = updateKey(ctx, cli, key, func(value []byte) ([]byte, error) {
+		if value == nil {
+			return nil, nil
+		}
+		current, err := decodeJSON(value)
+		if err != nil {
+			// nothing left to remove in a document which is not JSON anymore
+			return value, nil
+		}
+		updated, removed := jsonPointerRemove(current, tokens)
+		if !removed {
+			return value, nil
+		}
+		return json.Marshal(updated)
+	})
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error removing %s from the value of key %s", path, key)))
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package provider
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -18,6 +17,7 @@ func resourcePermission() *schema.Resource {
 		UpdateContext: resourcePermissionUpdate,
 		DeleteContext: resourcePermissionDelete,
 		Schema: map[string]*schema.Schema{
+			"cluster": resourceClusterSchema(),
 			"role": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -48,19 +48,40 @@ func resourcePermission() *schema.Resource {
 			},
 		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateCluster,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(defaultTimeout),
+		},
+		CustomizeDiff: resourcePermissionCustomizeDiff,
+	}
+}
+
+func resourcePermissionCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if meta == nil || !diffHasChanges(d) || !d.NewValueKnown("key") || !d.NewValueKnown("endrange") {
+		return nil
+	}
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return err
+	}
+
+	key := d.Get("key").(string)
+	rangeEnd := d.Get("endrange").(string)
+	if d.Get("withprefix").(bool) {
+		rangeEnd = clientv3.GetPrefixRangeEnd(key)
 	}
+	return cli.guardrails.checkRange(key, rangeEnd)
 }
 
-func resourcePermissionCreate(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
+func resourcePermissionCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	var rangeEnd string
 	var permissionType clientv3.PermissionType
 
-	cli := meta.(*clientv3.Client)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	defer cancel()
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	role := d.Get("role").(string)
 	key := d.Get("key").(string)
@@ -79,8 +100,11 @@ func resourcePermissionCreate(_ context.Context, d *schema.ResourceData, meta in
 	} else {
 		permissionType = clientv3.PermissionType(clientv3.PermRead)
 	}
-	_, err := cli.RoleGrantPermission(ctx, role, key, rangeEnd, permissionType)
-	//cancel()
+	if err := cli.guardrails.checkRange(key, rangeEnd); err != nil {
+		return diag.FromErr(err)
+	}
+	key, rangeEnd = cli.namespacedRange(key, rangeEnd)
+	_, err = cli.RoleGrantPermission(ctx, role, key, rangeEnd, permissionType)
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed creating permission: %v to key: %v into role: %v", permissionType, key, role)))
 	}
@@ -91,15 +115,14 @@ func resourcePermissionCreate(_ context.Context, d *schema.ResourceData, meta in
 }
 
 func resourcePermissionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-
-	cli := meta.(*clientv3.Client)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	role := d.Get("role").(string)
-	key := d.Get("key").(string)
+	key, _ := cli.namespacedRange(d.Get("key").(string), "")
 	resp, err := cli.RoleGet(ctx, role)
-	cancel()
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed getting role: %v", role)))
 	}
@@ -120,13 +143,13 @@ func resourcePermissionRead(ctx context.Context, d *schema.ResourceData, meta in
 }
 
 func resourcePermissionUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
 	var rangeEnd string
 	var permission clientv3.PermissionType
 
-	cli := meta.(*clientv3.Client)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	defer cancel()
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	role := d.Get("role").(string)
 	key := d.Get("key").(string)
 	withPrefix := d.Get("withprefix").(bool)
@@ -143,8 +166,11 @@ func resourcePermissionUpdate(ctx context.Context, d *schema.ResourceData, meta
 	} else {
 		permission = clientv3.PermissionType(clientv3.PermRead)
 	}
-	_, err := cli.RoleGrantPermission(ctx, role, key, rangeEnd, permission)
-	cancel()
+	if err := cli.guardrails.checkRange(key, rangeEnd); err != nil {
+		return diag.FromErr(err)
+	}
+	key, rangeEnd = cli.namespacedRange(key, rangeEnd)
+	_, err = cli.RoleGrantPermission(ctx, role, key, rangeEnd, permission)
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed creating permission: %v to key: %v into role: %v", permission, key, role)))
 	}
@@ -154,19 +180,23 @@ func resourcePermissionUpdate(ctx context.Context, d *schema.ResourceData, meta
 	return resourcePermissionRead(ctx, d, meta)
 }
 
-func resourcePermissionDelete(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-
-	cli := meta.(*clientv3.Client)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	defer cancel()
+func resourcePermissionDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	role := d.Get("role").(string)
-	key := d.Get("key").(string)
-	rangeEnd := d.Get("endrange").(string)
+	guardedRangeEnd := d.Get("endrange").(string)
+	if d.Get("withprefix").(bool) {
+		guardedRangeEnd = clientv3.GetPrefixRangeEnd(d.Get("key").(string))
+	}
+	if err := cli.guardrails.checkRange(d.Get("key").(string), guardedRangeEnd); err != nil {
+		return diag.FromErr(err)
+	}
+	key, rangeEnd := cli.namespacedRange(d.Get("key").(string), d.Get("endrange").(string))
 
 	resp, err := cli.RoleGet(ctx, role)
-	//cancel()
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed getting role: %v", role)))
 	}
@@ -0,0 +1,321 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/mirror"
+)
+
+func resourcePrefixMirror() *schema.Resource {
+	return &schema.Resource{
+		Description: "Copies every key under a prefix to another prefix, possibly on another cluster. " +
+			"Later applies only write the differences, and `prune` removes the keys which are gone from the source.",
+		CreateContext: resourcePrefixMirrorCreate,
+		ReadContext:   resourcePrefixMirrorRead,
+		UpdateContext: resourcePrefixMirrorUpdate,
+		DeleteContext: resourcePrefixMirrorDelete,
+		Schema: map[string]*schema.Schema{
+			"cluster": resourceClusterSchema(),
+			"source_cluster": {
+				Description: "Name of the `cluster` block of the provider to copy the keys from. Defaults to `cluster`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"source_prefix": {
+				Description:  "Prefix of the keys to copy.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateNotEmpty,
+			},
+			"destination_prefix": {
+				Description: "Prefix the keys are copied under, replacing `source_prefix`. Changing it moves the keys " +
+					"already copied to the new prefix in transactions, instead of deleting and copying them again.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateNotEmpty,
+			},
+			"prune": {
+				Description: "Delete the keys under `destination_prefix` which do not exist under `source_prefix`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+			},
+			"delete_on_destroy": {
+				Description: "Delete every key under `destination_prefix` when the resource is destroyed. " +
+					"By default the copied keys are left in place.",
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"source_revision": {
+				Description: "Revision of the source cluster the keys were last copied at.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"key_count": {
+				Description: "Number of keys under `source_prefix`.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"in_sync": {
+				Description: "Whether the keys under `destination_prefix` match the ones under `source_prefix`. " +
+					"When they do not, the next apply copies the differences.",
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(defaultTimeout),
+		},
+		CustomizeDiff: resourcePrefixMirrorCustomizeDiff,
+	}
+}
+
+func resourcePrefixMirrorCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if meta == nil {
+		return nil
+	}
+
+	// the source changed since the last apply, copy it again
+	if d.Id() != "" && !d.Get("in_sync").(bool) {
+		if err := d.SetNew("in_sync", true); err != nil {
+			return err
+		}
+		if err := d.SetNewComputed("source_revision"); err != nil {
+			return err
+		}
+	}
+
+	if !diffHasChanges(d) || !d.NewValueKnown("destination_prefix") {
+		return nil
+	}
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return err
+	}
+	dest := d.Get("destination_prefix").(string)
+	return cli.guardrails.checkRange(dest, clientv3.GetPrefixRangeEnd(dest))
+}
+
+// prefixMirrorClients returns the source and destination clients of d.
+func prefixMirrorClients(d clusterGetter, meta interface{}) (*apiClient, *apiClient, error) {
+	dst, err := clientFor(d, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := d.Get("source_cluster").(string)
+	if name == "" {
+		return dst, dst, nil
+	}
+	src, err := meta.(*clientRegistry).client(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return src, dst, nil
+}
+
+// prefixMirrorSnapshot returns the keys under prefix, with their values, as
+// seen at a single revision of src, which is returned as well.
+func prefixMirrorSnapshot(ctx context.Context, src *apiClient, prefix string) (map[string]string, int64, error) {
+	resp, err := src.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return nil, 0, err
+	}
+	rev := resp.Header.Revision
+
+	kvs := make(map[string]string, resp.Count)
+	respc, errc := mirror.NewSyncer(src.Client, prefix, rev).SyncBase(ctx)
+	for r := range respc {
+		for _, kv := range r.Kvs {
+			kvs[string(kv.Key)] = string(kv.Value)
+		}
+	}
+	if err := <-errc; err != nil {
+		return nil, 0, err
+	}
+	return kvs, rev, nil
+}
+
+// prefixMirrorOps returns the operations making the keys under the destination
+// prefix of d match the source keys of the snapshot.
+func prefixMirrorOps(ctx context.Context, d *schema.ResourceData, dst *apiClient, snapshot map[string]string) ([]clientv3.Op, error) {
+	src := d.Get("source_prefix").(string)
+	dest := d.Get("destination_prefix").(string)
+
+	current, _, err := getRange(ctx, dst, rangeQuery{key: dest, rangeEnd: clientv3.GetPrefixRangeEnd(dest)})
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]string, len(current))
+	for _, kv := range current {
+		existing[string(kv.Key)] = string(kv.Value)
+	}
+
+	var ops []clientv3.Op
+	desired := make(map[string]bool, len(snapshot))
+	for key, value := range snapshot {
+		destKey := dest + strings.TrimPrefix(key, src)
+		desired[destKey] = true
+		if v, ok := existing[destKey]; !ok || v != value {
+			ops = append(ops, clientv3.OpPut(destKey, value))
+		}
+	}
+	if d.Get("prune").(bool) {
+		for _, kv := range current {
+			if !desired[string(kv.Key)] {
+				ops = append(ops, clientv3.OpDelete(string(kv.Key)))
+			}
+		}
+	}
+	return ops, nil
+}
+
+func resourcePrefixMirrorCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := resourcePrefixMirrorSync(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+
+	d.SetId(uuidGenerator())
+
+	return resourcePrefixMirrorRead(ctx, d, meta)
+}
+
+func resourcePrefixMirrorUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.HasChange("destination_prefix") {
+		cli, err := clientFor(d, meta)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		oldPrefix, newPrefix := d.GetChange("destination_prefix")
+		from, to := oldPrefix.(string), newPrefix.(string)
+		if err := cli.guardrails.checkRange(from, clientv3.GetPrefixRangeEnd(from)); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := cli.guardrails.checkRange(to, clientv3.GetPrefixRangeEnd(to)); err != nil {
+			return diag.FromErr(err)
+		}
+
+		moved, err := movePrefix(ctx, cli, from, to, cli.guardrails.checkDelete)
+		if err != nil {
+			return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error moving the keys under %s to %s", from, to)))
+		}
+		tflog.Debug(ctx, fmt.Sprintf("Moved %d keys from %s to %s", moved, from, to))
+	}
+
+	if diags := resourcePrefixMirrorSync(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+
+	return resourcePrefixMirrorRead(ctx, d, meta)
+}
+
+// resourcePrefixMirrorSync copies a snapshot of the source prefix to the
+// destination prefix, writing only the keys which differ.
+func resourcePrefixMirrorSync(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	src, dst, err := prefixMirrorClients(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	srcPrefix := d.Get("source_prefix").(string)
+	destPrefix := d.Get("destination_prefix").(string)
+	if src == dst && rangesOverlap(srcPrefix, clientv3.GetPrefixRangeEnd(srcPrefix), destPrefix, clientv3.GetPrefixRangeEnd(destPrefix)) {
+		return diag.Errorf("source_prefix %q and destination_prefix %q overlap", srcPrefix, destPrefix)
+	}
+	if err := dst.guardrails.checkRange(destPrefix, clientv3.GetPrefixRangeEnd(destPrefix)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	snapshot, rev, err := prefixMirrorSnapshot(ctx, src, srcPrefix)
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading the keys under %s", srcPrefix)))
+	}
+	ops, err := prefixMirrorOps(ctx, d, dst, snapshot)
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading the keys under %s", destPrefix)))
+	}
+	for _, op := range ops {
+		if op.IsDelete() {
+			if err := dst.guardrails.checkDelete(string(op.KeyBytes())); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Mirroring %d keys of %s at revision %d to %s with %d changes", len(snapshot), srcPrefix, rev, destPrefix, len(ops)))
+
+	if err := commitOps(ctx, dst, ops); err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error writing the keys under %s", destPrefix)))
+	}
+
+	if err := d.Set("source_revision", int(rev)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourcePrefixMirrorRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	src, dst, err := prefixMirrorClients(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	srcPrefix := d.Get("source_prefix").(string)
+	snapshot, _, err := prefixMirrorSnapshot(ctx, src, srcPrefix)
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading the keys under %s", srcPrefix)))
+	}
+	ops, err := prefixMirrorOps(ctx, d, dst, snapshot)
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading the keys under %s", d.Get("destination_prefix"))))
+	}
+
+	if err := d.Set("key_count", len(snapshot)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("in_sync", len(ops) == 0); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourcePrefixMirrorDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if !d.Get("delete_on_destroy").(bool) {
+		return nil
+	}
+
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	dest := d.Get("destination_prefix").(string)
+	if err := cli.guardrails.checkRange(dest, clientv3.GetPrefixRangeEnd(dest)); err != nil {
+		return diag.FromErr(err)
+	}
+	kvs, _, err := getRange(ctx, cli, rangeQuery{key: dest, rangeEnd: clientv3.GetPrefixRangeEnd(dest), keysOnly: true})
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Failed reading the keys under %s", dest)))
+	}
+
+	ops := make([]clientv3.Op, 0, len(kvs))
+	for _, kv := range kvs {
+		if err := cli.guardrails.checkDelete(string(kv.Key)); err != nil {
+			return diag.FromErr(err)
+		}
+		ops = append(ops, clientv3.OpDelete(string(kv.Key)))
+	}
+	if err := commitOps(ctx, cli, ops); err != nil {
+		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("Error deleting the keys under %s", dest)))
+	}
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -19,26 +18,33 @@ func resourceRole() *schema.Resource {
 		UpdateContext: resourceRoleUpdate,
 		DeleteContext: resourceRoleDelete,
 		Schema: map[string]*schema.Schema{
+			"cluster": resourceClusterSchema(),
 			"name": {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
 		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateCluster,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(defaultTimeout),
+		},
+		CustomizeDiff: customizeDiffReadOnly,
 	}
 }
 
-func resourceRoleCreate(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-
-	cli := meta.(*clientv3.Client)
+func resourceRoleCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := cli.guardrails.checkReadOnly(); err != nil {
+		return diag.FromErr(err)
+	}
 
 	role := d.Get("name").(string)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	_, err := cli.RoleGet(ctx, role)
-	defer cancel()
+	_, err = cli.RoleGet(ctx, role)
 	if err == nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("The role %v already exist and it is not managed by this terraform.", role)))
 	}
@@ -53,19 +59,18 @@ func resourceRoleCreate(_ context.Context, d *schema.ResourceData, meta interfac
 	return resourceRoleRead(ctx, d, meta)
 }
 
-func resourceRoleRead(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-
-	cli := meta.(*clientv3.Client)
+func resourceRoleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	role := d.Get("name").(string)
 	if role == "" {
 		role = d.Id()
 		d.Set("name", role)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	_, err := cli.RoleGet(ctx, role)
-	defer cancel()
+	_, err = cli.RoleGet(ctx, role)
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("The role %s doesn't exist. Maybe someone removed it manually.", role)))
 	}
@@ -74,16 +79,23 @@ func resourceRoleRead(_ context.Context, d *schema.ResourceData, meta interface{
 
 	return nil
 }
-func resourceRoleUpdate(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-
+func resourceRoleUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	oldValue, newValue := d.GetChange("name")
-	cli := meta.(*clientv3.Client)
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := cli.guardrails.checkReadOnly(); err != nil {
+		return diag.FromErr(err)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	tflog.Info(ctx, fmt.Sprintf("oldvalue is: %v, newValue is: %v", oldValue, newValue))
+	if oldValue == "root" {
+		if diags := cli.checkRootChange(ctx, "rename the root role"); diags.HasError() {
+			return diags
+		}
+	}
 	role, err := cli.RoleGet(ctx, fmt.Sprintf("%v", oldValue))
-	defer cancel()
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("The original role %v doesn't exist.", oldValue)))
 	}
@@ -121,20 +133,25 @@ func resourceRoleUpdate(_ context.Context, d *schema.ResourceData, meta interfac
 }
 
 func resourceRoleDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-
-	cli := meta.(*clientv3.Client)
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := cli.guardrails.checkReadOnly(); err != nil {
+		return diag.FromErr(err)
+	}
 
 	name := d.Get("name").(string)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	_, err := cli.RoleGet(ctx, name)
-	cancel()
+	if name == "root" {
+		if diags := cli.checkRootChange(ctx, "delete the root role"); diags.HasError() {
+			return diags
+		}
+	}
+	_, err = cli.RoleGet(ctx, name)
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("The role %s doesn't exist", name)))
 	}
-	ctx, cancel = context.WithTimeout(context.Background(), requestTimeout)
 	_, err = cli.RoleDelete(ctx, name)
-	cancel()
 	if err != nil {
 		return diag.FromErr(err)
 	}
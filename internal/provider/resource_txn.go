@@ -0,0 +1,295 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var (
+	txnCompareTargets = []string{"VALUE", "VERSION", "CREATE", "MOD", "LEASE"}
+	txnCompareResults = []string{"=", "!=", "<", ">"}
+	txnOpTypes        = []string{"PUT", "DELETE", "DELETE_PREFIX"}
+)
+
+func resourceTxn() *schema.Resource {
+	return &schema.Resource{
+		Description: "Runs an etcd transaction on create and update: the `success` operations when every `compare` " +
+			"holds, the `failure` operations otherwise, all atomically.",
+		CreateContext: resourceTxnCreate,
+		ReadContext:   resourceTxnRead,
+		UpdateContext: resourceTxnUpdate,
+		DeleteContext: resourceTxnDelete,
+		Schema: map[string]*schema.Schema{
+			"cluster": resourceClusterSchema(),
+			"compare": {
+				Description: "Conditions of the transaction, all of which must hold for `success` to run.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Description:  "Key compared.",
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateNotEmpty,
+						},
+						"target": {
+							Description:  "Field of the key compared: VALUE, VERSION, CREATE (revision), MOD (revision) or LEASE.",
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateStringIn(txnCompareTargets...),
+						},
+						"result": {
+							Description:  "Comparison: =, !=, < or >.",
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "=",
+							ValidateFunc: validateStringIn(txnCompareResults...),
+						},
+						"value": {
+							Description: "Value the field is compared to, an integer for every target but VALUE. " +
+								"A missing key has a version, revisions and lease of 0.",
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"success": txnOpsSchema("Operations run when every `compare` holds."),
+			"failure": txnOpsSchema("Operations run when a `compare` does not hold."),
+			"triggers": {
+				Description: "Arbitrary values which run the transaction again when they change.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"succeeded": {
+				Description: "Whether every `compare` held, and `success` ran rather than `failure`.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"revision": {
+				Description: "Revision of the cluster after the transaction.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(defaultTimeout),
+		},
+		CustomizeDiff: resourceTxnCustomizeDiff,
+	}
+}
+
+func txnOpsSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Description: description,
+		Type:        schema.TypeList,
+		Optional:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"type": {
+					Description:  "Operation: PUT, DELETE or DELETE_PREFIX.",
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "PUT",
+					ValidateFunc: validateStringIn(txnOpTypes...),
+				},
+				"key": {
+					Description:  "Key written or deleted, or prefix of the keys deleted by DELETE_PREFIX.",
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: validateNotEmpty,
+				},
+				"value": {
+					Description: "Value written by PUT.",
+					Type:        schema.TypeString,
+					Optional:    true,
+				},
+			},
+		},
+	}
+}
+
+func resourceTxnCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if meta == nil || !diffHasChanges(d) {
+		return nil
+	}
+
+	// the transaction runs again on any change
+	if d.Id() != "" {
+		if err := d.SetNewComputed("succeeded"); err != nil {
+			return err
+		}
+		if err := d.SetNewComputed("revision"); err != nil {
+			return err
+		}
+	}
+
+	if !d.NewValueKnown("success") || !d.NewValueKnown("failure") {
+		return nil
+	}
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return err
+	}
+	return txnCheckOps(cli.guardrails, d.Get("success").([]interface{}), d.Get("failure").([]interface{}))
+}
+
+// txnCheckOps returns an error when the guardrails forbid one of the
+// operations of ops, or when one of them has an empty key, which DELETE_PREFIX
+// would take for the whole keyspace.
+func txnCheckOps(g guardrails, ops ...[]interface{}) error {
+	for _, l := range ops {
+		for _, v := range l {
+			op := v.(map[string]interface{})
+			key := op["key"].(string)
+			if key == "" {
+				return fmt.Errorf("the key of a %s operation must not be empty", op["type"])
+			}
+
+			var err error
+			switch op["type"].(string) {
+			case "DELETE":
+				err = g.checkDelete(key)
+			case "DELETE_PREFIX":
+				if err = g.checkRange(key, clientv3.GetPrefixRangeEnd(key)); err == nil {
+					for _, p := range g.protectedKeys {
+						if strings.HasPrefix(p, key) {
+							err = fmt.Errorf("key %q is protected and cannot be deleted", p)
+						}
+					}
+				}
+			default:
+				err = g.checkWrite(key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// txnCompares builds the conditions of the compare blocks l.
+func txnCompares(l []interface{}) ([]clientv3.Cmp, error) {
+	cmps := make([]clientv3.Cmp, 0, len(l))
+	for _, v := range l {
+		c := v.(map[string]interface{})
+		key := c["key"].(string)
+		target := c["target"].(string)
+		result := c["result"].(string)
+		value := c["value"].(string)
+
+		if target == "VALUE" {
+			cmps = append(cmps, clientv3.Compare(clientv3.Value(key), result, value))
+			continue
+		}
+
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("the value compared to the %s of %q must be an integer, got: %q", target, key, value)
+		}
+		var cmp clientv3.Cmp
+		switch target {
+		case "VERSION":
+			cmp = clientv3.Version(key)
+		case "CREATE":
+			cmp = clientv3.CreateRevision(key)
+		case "MOD":
+			cmp = clientv3.ModRevision(key)
+		case "LEASE":
+			cmp = clientv3.LeaseValue(key)
+		}
+		cmps = append(cmps, clientv3.Compare(cmp, result, n))
+	}
+	return cmps, nil
+}
+
+// txnOps builds the operations of the success or failure blocks l.
+func txnOps(l []interface{}) []clientv3.Op {
+	ops := make([]clientv3.Op, 0, len(l))
+	for _, v := range l {
+		op := v.(map[string]interface{})
+		key := op["key"].(string)
+
+		switch op["type"].(string) {
+		case "DELETE":
+			ops = append(ops, clientv3.OpDelete(key))
+		case "DELETE_PREFIX":
+			ops = append(ops, clientv3.OpDelete(key, clientv3.WithPrefix()))
+		default:
+			ops = append(ops, clientv3.OpPut(key, op["value"].(string)))
+		}
+	}
+	return ops
+}
+
+func resourceTxnCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := resourceTxnCommit(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+
+	d.SetId(uuidGenerator())
+
+	return resourceTxnRead(ctx, d, meta)
+}
+
+func resourceTxnUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := resourceTxnCommit(ctx, d, meta); diags.HasError() {
+		return diags
+	}
+
+	return resourceTxnRead(ctx, d, meta)
+}
+
+// resourceTxnCommit runs the transaction described by d.
+func resourceTxnCommit(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	success := d.Get("success").([]interface{})
+	failure := d.Get("failure").([]interface{})
+	if err := txnCheckOps(cli.guardrails, success, failure); err != nil {
+		return diag.FromErr(err)
+	}
+	cmps, err := txnCompares(d.Get("compare").([]interface{}))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	resp, err := cli.Txn(ctx).If(cmps...).Then(txnOps(success)...).Else(txnOps(failure)...).Commit()
+	if err != nil {
+		return diag.FromErr(errors.Wrap(err, "Error running the transaction in etcd server"))
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Transaction succeeded: %v, revision: %d", resp.Succeeded, resp.Header.Revision))
+
+	if err := d.Set("succeeded", resp.Succeeded); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("revision", int(resp.Header.Revision)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// resourceTxnRead has nothing to read, a transaction leaves nothing behind but
+// its outcome, recorded when it ran.
+func resourceTxnRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
+
+func resourceTxnDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
@@ -7,11 +7,9 @@ import (
 	"github.com/pkg/errors"
 	"math/rand"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 var (
@@ -29,6 +27,7 @@ func resourceUser() *schema.Resource {
 		UpdateContext: resourceUserUpdate,
 		DeleteContext: resourceUserDelete,
 		Schema: map[string]*schema.Schema{
+			"cluster": resourceClusterSchema(),
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -39,8 +38,12 @@ func resourceUser() *schema.Resource {
 			},
 		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateCluster,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(defaultTimeout),
+		},
+		CustomizeDiff: customizeDiffReadOnly,
 	}
 }
 
@@ -77,10 +80,14 @@ func generatePassword(passwdLen, nSpecialChar, nNum, nUpperCase int) string {
 	return string(inRune)
 }
 
-func resourceUserCreate(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-
-	cli := meta.(*clientv3.Client)
+func resourceUserCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := cli.guardrails.checkReadOnly(); err != nil {
+		return diag.FromErr(err)
+	}
 
 	name := d.Get("name").(string)
 	password := d.Get("password").(string)
@@ -88,10 +95,8 @@ func resourceUserCreate(_ context.Context, d *schema.ResourceData, meta interfac
 		password = generatePassword(24, 3, 3, 3)
 		d.Set("password", password)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	defer cancel()
 
-	_, err := cli.UserAdd(ctx, name, password)
+	_, err = cli.UserAdd(ctx, name, password)
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("A problem occurred with user creation %s", name)))
 	}
@@ -101,19 +106,18 @@ func resourceUserCreate(_ context.Context, d *schema.ResourceData, meta interfac
 	return resourceUserRead(ctx, d, meta)
 }
 
-func resourceUserRead(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-
-	cli := meta.(*clientv3.Client)
+func resourceUserRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	name := d.Get("name").(string)
 	if name == "" {
 		name = d.Id()
 		d.Set("name", name)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	_, err := cli.UserGet(ctx, name)
-	defer cancel()
+	_, err = cli.UserGet(ctx, name)
 	if err != nil {
 		return diag.FromErr(errors.Wrap(err, fmt.Sprintf("The user %v doesn't exist. Maybe someone removed that manually.", name)))
 	}
@@ -122,12 +126,15 @@ func resourceUserRead(_ context.Context, d *schema.ResourceData, meta interface{
 	return nil
 }
 
-func resourceUserUpdate(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
+func resourceUserUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	var name string
-	cli := meta.(*clientv3.Client)
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	defer cancel()
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := cli.guardrails.checkReadOnly(); err != nil {
+		return diag.FromErr(err)
+	}
 
 	oldValueName, newValueName := d.GetChange("name")
 	_, newValuePassword := d.GetChange("password")
@@ -142,6 +149,11 @@ func resourceUserUpdate(_ context.Context, d *schema.ResourceData, meta interfac
 		}
 	} else {
 		name = fmt.Sprintf("%s", newValueName)
+		if oldValueName == "root" {
+			if diags := cli.checkRootChange(ctx, "rename the root user"); diags.HasError() {
+				return diags
+			}
+		}
 		tflog.Debug(ctx, fmt.Sprintf("Going to remove user: %s", oldValueName))
 		_, errUserDelete := cli.UserDelete(ctx, fmt.Sprintf("%s", oldValueName))
 		if errUserDelete != nil {
@@ -157,15 +169,22 @@ func resourceUserUpdate(_ context.Context, d *schema.ResourceData, meta interfac
 	return resourceUserRead(ctx, d, meta)
 }
 
-func resourceUserDelete(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var requestTimeout = 5 * time.Second
-
-	cli := meta.(*clientv3.Client)
+func resourceUserDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cli, err := clientFor(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := cli.guardrails.checkReadOnly(); err != nil {
+		return diag.FromErr(err)
+	}
 	name := d.Get("name").(string)
+	if name == "root" {
+		if diags := cli.checkRootChange(ctx, "delete the root user"); diags.HasError() {
+			return diags
+		}
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
-	_, err := cli.UserDelete(ctx, name)
-	defer cancel()
+	_, err = cli.UserDelete(ctx, name)
 
 	tflog.Info(ctx, fmt.Sprintf("Going to remove user: %s", name))
 
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const retryBaseBackoff = 100 * time.Millisecond
+
+// idempotentMethods lists the etcd RPCs which can be sent again after an
+// ambiguous failure, because applying them twice has the same effect as
+// applying them once. Txn, UserAdd, RoleAdd and the deletions of users, roles
+// and grants fail when they are replayed after a success, so they are left out.
+// So are Put and DeleteRange: a replayed Put with a lease or prev_kv, or a
+// DeleteRange after a write of the range, does not have the same effect.
+var idempotentMethods = map[string]bool{
+	"/etcdserverpb.KV/Range":                 true,
+	"/etcdserverpb.Auth/AuthStatus":          true,
+	"/etcdserverpb.Auth/Authenticate":        true,
+	"/etcdserverpb.Auth/UserGet":             true,
+	"/etcdserverpb.Auth/UserList":            true,
+	"/etcdserverpb.Auth/UserChangePassword":  true,
+	"/etcdserverpb.Auth/UserGrantRole":       true,
+	"/etcdserverpb.Auth/RoleGet":             true,
+	"/etcdserverpb.Auth/RoleList":            true,
+	"/etcdserverpb.Auth/RoleGrantPermission": true,
+	"/etcdserverpb.Cluster/MemberList":       true,
+	"/etcdserverpb.Maintenance/Status":       true,
+	"/etcdserverpb.Maintenance/Defragment":   true,
+	"/etcdserverpb.Maintenance/HashKV":       true,
+}
+
+// retryable reports whether the RPC method which failed with err can be sent
+// again. Errors returned before a request reaches raft, such as no leader or
+// too many requests, are retryable for every method. Errors which leave the
+// outcome unknown, such as timeouts, are only retryable for idempotent methods.
+func retryable(method string, err error) bool {
+	switch rpctypes.Error(err) {
+	case rpctypes.ErrNoLeader, rpctypes.ErrTooManyRequests, rpctypes.ErrNotCapable:
+		return true
+	case rpctypes.ErrTimeout, rpctypes.ErrTimeoutDueToLeaderFail, rpctypes.ErrTimeoutDueToConnectionLost,
+		rpctypes.ErrLeaderChanged, rpctypes.ErrTimeoutWaitAppliedIndex, rpctypes.ErrUnhealthy:
+		return idempotentMethods[method]
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return idempotentMethods[method]
+	}
+	return false
+}
+
+// retryInterceptor sends the unary etcd requests failing with a retryable error
+// again, up to maxRetries times, with an exponential backoff capped at
+// maxBackoff and full jitter. It gives up as soon as ctx is done, so retries
+// never outlive the timeouts block of the resource.
+//
+// The etcd client runs the interceptors of the provider inside its own retry
+// interceptor, which sends the reads failing with codes.Unavailable again on
+// top of these retries. So maxRetries bounds the retries of the provider only,
+// and request_timeout, applied outside the client, bounds a request with every
+// retry of both.
+func retryInterceptor(maxRetries int, maxBackoff time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		backoff := retryBaseBackoff
+		for attempt := 0; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= maxRetries || ctx.Err() != nil || !retryable(method, err) {
+				return err
+			}
+
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			wait := time.Duration(rand.Int63n(int64(backoff) + 1))
+			tflog.Warn(ctx, fmt.Sprintf("Retrying %s in %s after error: %s", method, wait, err))
+
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(wait):
+			}
+			backoff *= 2
+		}
+	}
+}
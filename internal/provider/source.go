@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// valueFormatSource is the format of the value of an etcd_key read from its
+// source file.
+const valueFormatSource = "source"
+
+// contentHash returns the hex encoded SHA-256 of value, used to notice changes
+// of local files without storing their content in the state.
+func contentHash(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
+
+func validateGlob(val interface{}, key string) (warns []string, errs []error) {
+	for _, segment := range strings.Split(val.(string), "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			errs = append(errs, fmt.Errorf("%q must be a glob pattern, got: %v", key, val))
+			return
+		}
+	}
+	return
+}
+
+// matchGlob reports whether the slash separated name matches pattern, a glob in
+// which "**" matches any number of path segments, including none, and the
+// other segments are matched by path.Match.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// matchAnyGlob reports whether name matches one of patterns.
+func matchAnyGlob(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// readSourceDir returns the content of the regular files under dir, indexed by
+// their slash separated path relative to dir, keeping the paths which match
+// one of include and none of exclude.
+func readSourceDir(dir string, include, exclude []string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !e.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !matchAnyGlob(include, rel) || matchAnyGlob(exclude, rel) {
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[rel] = content
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	valueFormatJSON   = "json"
+	valueFormatYAML   = "yaml"
+	valueFormatBase64 = "base64"
+)
+
+var valueFormats = []string{valueFormatJSON, valueFormatYAML}
+
+// decodeValue parses value, a JSON or YAML document depending on format. JSON
+// numbers are kept as they are written, so large integers do not lose their
+// precision.
+func decodeValue(format, value string) (interface{}, error) {
+	switch format {
+	case valueFormatJSON:
+		return decodeJSON([]byte(value))
+	case valueFormatYAML:
+		var v interface{}
+		if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+			return nil, err
+		}
+		return jsonCompatible(v), nil
+	}
+	return nil, fmt.Errorf("unknown value format %q", format)
+}
+
+// normalizeValue returns value, a JSON or YAML document depending on format, as
+// compact JSON with sorted keys, so documents which only differ by their
+// formatting normalize to the same string.
+func normalizeValue(format, value string) (string, error) {
+	v, err := decodeValue(format, value)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// jsonCompatible converts the maps decoded from YAML, which may have keys of
+// any type, to maps with string keys.
+func jsonCompatible(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonCompatible(e)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprintf("%v", k)] = jsonCompatible(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonCompatible(e)
+		}
+		return v
+	}
+	return v
+}
+
+// validateValue checks the argument is a valid JSON or YAML document depending
+// on format.
+func validateValue(format string) schema.SchemaValidateFunc {
+	return func(val interface{}, key string) (warns []string, errs []error) {
+		if _, err := decodeValue(format, val.(string)); err != nil {
+			errs = append(errs, fmt.Errorf("%q must be a valid %s document: %v", key, format, err))
+		}
+		return
+	}
+}
+
+// suppressEquivalentValue ignores the changes of a JSON or YAML document which
+// do not change its content, such as indentation or the order of the keys.
+func suppressEquivalentValue(format string) schema.SchemaDiffSuppressFunc {
+	return func(k, old, new string, d *schema.ResourceData) bool {
+		o, err := normalizeValue(format, old)
+		if err != nil {
+			return false
+		}
+		n, err := normalizeValue(format, new)
+		if err != nil {
+			return false
+		}
+		return o == n
+	}
+}
+
+// valueObjectSchema is the output of the data sources holding a decoded value.
+func valueObjectSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Value decoded according to `decode`, encoded as JSON. Use `jsondecode()` to consume it.",
+		Type:        schema.TypeString,
+		Computed:    true,
+	}
+}
+
+// decodeSchema is the argument of the data sources selecting how values are
+// decoded into `value_object`.
+func decodeSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Format of the values, \"json\" or \"yaml\", decoded into `value_object`. By default values " +
+			"are not decoded and `value_object` is empty. When several keys are read, a value which does not decode " +
+			"leaves the `value_object` of its entry empty, with a warning.",
+		Type:         schema.TypeString,
+		Optional:     true,
+		ValidateFunc: validateStringIn(valueFormats...),
+	}
+}
+
+// valueObject returns the value_object of a data source, value decoded
+// according to format, or "" when format is empty.
+func valueObject(format, key, value string) (string, error) {
+	if format == "" {
+		return "", nil
+	}
+	v, err := normalizeValue(format, value)
+	if err != nil {
+		return "", fmt.Errorf("the value of key %q is not a valid %s document: %v", key, format, err)
+	}
+	return v, nil
+}
+
+// entryValueObject returns the value_object of an entry of a data source
+// reading several keys. A value which does not decode leaves its entry empty,
+// with a warning, instead of failing the whole read.
+func entryValueObject(format, key, value string) (string, diag.Diagnostics) {
+	object, err := valueObject(format, key, value)
+	if err != nil {
+		return "", diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Value not decoded",
+			Detail:   err.Error(),
+		}}
+	}
+	return object, nil
+}
+
+func validateBase64(val interface{}, key string) (warns []string, errs []error) {
+	if _, err := base64.StdEncoding.DecodeString(val.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%q must be base64 encoded: %v", key, err))
+	}
+	return
+}
+
+// valueBase64Schema is the output of the data sources holding a value base64
+// encoded.
+func valueBase64Schema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Value, base64 encoded. Use it for values which are not UTF-8 text, `value` is empty for them.",
+		Type:        schema.TypeString,
+		Computed:    true,
+	}
+}
+
+// textValue returns value as a string, or "" when it is not valid UTF-8 and
+// would be corrupted in the state.
+func textValue(value []byte) string {
+	if !utf8.Valid(value) {
+		return ""
+	}
+	return string(value)
+}
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeValue(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		value  string
+		want   interface{}
+		err    bool
+	}{
+		{name: "json object", format: valueFormatJSON, value: `{"a": [1, "b", null]}`, want: map[string]interface{}{"a": []interface{}{json.Number("1"), "b", nil}}},
+		{name: "json large integer", format: valueFormatJSON, value: `9007199254740993`, want: json.Number("9007199254740993")},
+		{name: "json trailing data", format: valueFormatJSON, value: `{} {}`, err: true},
+		{name: "json invalid", format: valueFormatJSON, value: `{`, err: true},
+		{name: "yaml mapping", format: valueFormatYAML, value: "a:\n  - b\n  - 1\n", want: map[string]interface{}{"a": []interface{}{"b", 1}}},
+		{name: "yaml integer keys", format: valueFormatYAML, value: "1: a\n", want: map[string]interface{}{"1": "a"}},
+		{name: "yaml invalid", format: valueFormatYAML, value: "a: [", err: true},
+		{name: "unknown format", format: "toml", value: `a = 1`, err: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := decodeValue(c.format, c.value)
+			if c.err {
+				if err == nil {
+					t.Fatalf("decodeValue(%q, %q) = %v, want an error", c.format, c.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("decodeValue(%q, %q) = %#v, want %#v", c.format, c.value, got, c.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeValue(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		value  string
+		want   string
+	}{
+		{name: "json formatting", format: valueFormatJSON, value: "{\n  \"b\": 1,\n  \"a\": true\n}", want: `{"a":true,"b":1}`},
+		{name: "json numbers as written", format: valueFormatJSON, value: `[9007199254740993, 1.50]`, want: `[9007199254740993,1.50]`},
+		{name: "yaml", format: valueFormatYAML, value: "b: 1\na: [x, y]\n", want: `{"a":["x","y"],"b":1}`},
+		{name: "yaml nested", format: valueFormatYAML, value: "a:\n  b:\n    c: d\n", want: `{"a":{"b":{"c":"d"}}}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := normalizeValue(c.format, c.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("normalizeValue(%q, %q) = %s, want %s", c.format, c.value, got, c.want)
+			}
+		})
+	}
+}
+
+func TestSuppressEquivalentValue(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   string
+		old, new string
+		want     bool
+	}{
+		{name: "json formatting", format: valueFormatJSON, old: `{"a":1,"b":2}`, new: "{\n  \"b\": 2,\n  \"a\": 1\n}", want: true},
+		{name: "json change", format: valueFormatJSON, old: `{"a":1}`, new: `{"a":2}`, want: false},
+		{name: "json large integer change", format: valueFormatJSON, old: `{"a":9007199254740993}`, new: `{"a":9007199254740992}`, want: false},
+		{name: "json invalid", format: valueFormatJSON, old: `{`, new: `{`, want: false},
+		{name: "yaml formatting", format: valueFormatYAML, old: "a: 1\nb: [x]\n", new: "b:\n  - x\na: 1\n", want: true},
+		{name: "yaml change", format: valueFormatYAML, old: "a: 1\n", new: "a: 2\n", want: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := suppressEquivalentValue(c.format)("value", c.old, c.new, nil); got != c.want {
+				t.Errorf("suppressEquivalentValue(%q)(%q, %q) = %v, want %v", c.format, c.old, c.new, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// The request_timeout of the provider is applied to the context of every etcd
+// request before it enters the client. The client retries the requests failing
+// with a context error while the context it was handed is still live, so a
+// deadline set inside the client, by an interceptor, would have any request,
+// writes included, sent again up to 100 times.
+
+// timeoutKV bounds every request of the KV API by timeout.
+type timeoutKV struct {
+	clientv3.KV
+	timeout time.Duration
+}
+
+func (kv *timeoutKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, kv.timeout)
+	defer cancel()
+	return kv.KV.Put(ctx, key, val, opts...)
+}
+
+func (kv *timeoutKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, kv.timeout)
+	defer cancel()
+	return kv.KV.Get(ctx, key, opts...)
+}
+
+func (kv *timeoutKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, kv.timeout)
+	defer cancel()
+	return kv.KV.Delete(ctx, key, opts...)
+}
+
+func (kv *timeoutKV) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, kv.timeout)
+	defer cancel()
+	return kv.KV.Compact(ctx, rev, opts...)
+}
+
+func (kv *timeoutKV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, kv.timeout)
+	defer cancel()
+	return kv.KV.Do(ctx, op)
+}
+
+func (kv *timeoutKV) Txn(ctx context.Context) clientv3.Txn {
+	return &timeoutTxn{kv: kv, ctx: ctx}
+}
+
+// timeoutTxn records a transaction and only starts its timeout on Commit.
+type timeoutTxn struct {
+	kv  *timeoutKV
+	ctx context.Context
+
+	cmps    []clientv3.Cmp
+	thenOps []clientv3.Op
+	elseOps []clientv3.Op
+}
+
+func (txn *timeoutTxn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	txn.cmps = append(txn.cmps, cs...)
+	return txn
+}
+
+func (txn *timeoutTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	txn.thenOps = append(txn.thenOps, ops...)
+	return txn
+}
+
+func (txn *timeoutTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	txn.elseOps = append(txn.elseOps, ops...)
+	return txn
+}
+
+func (txn *timeoutTxn) Commit() (*clientv3.TxnResponse, error) {
+	ctx, cancel := context.WithTimeout(txn.ctx, txn.kv.timeout)
+	defer cancel()
+	return txn.kv.KV.Txn(ctx).If(txn.cmps...).Then(txn.thenOps...).Else(txn.elseOps...).Commit()
+}
+
+// timeoutAuth bounds every request of the Auth API by timeout.
+type timeoutAuth struct {
+	clientv3.Auth
+	timeout time.Duration
+}
+
+func (a *timeoutAuth) Authenticate(ctx context.Context, name string, password string) (*clientv3.AuthenticateResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.Authenticate(ctx, name, password)
+}
+
+func (a *timeoutAuth) AuthEnable(ctx context.Context) (*clientv3.AuthEnableResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.AuthEnable(ctx)
+}
+
+func (a *timeoutAuth) AuthDisable(ctx context.Context) (*clientv3.AuthDisableResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.AuthDisable(ctx)
+}
+
+func (a *timeoutAuth) AuthStatus(ctx context.Context) (*clientv3.AuthStatusResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.AuthStatus(ctx)
+}
+
+func (a *timeoutAuth) UserAdd(ctx context.Context, name string, password string) (*clientv3.AuthUserAddResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.UserAdd(ctx, name, password)
+}
+
+func (a *timeoutAuth) UserAddWithOptions(ctx context.Context, name string, password string, opt *clientv3.UserAddOptions) (*clientv3.AuthUserAddResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.UserAddWithOptions(ctx, name, password, opt)
+}
+
+func (a *timeoutAuth) UserDelete(ctx context.Context, name string) (*clientv3.AuthUserDeleteResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.UserDelete(ctx, name)
+}
+
+func (a *timeoutAuth) UserChangePassword(ctx context.Context, name string, password string) (*clientv3.AuthUserChangePasswordResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.UserChangePassword(ctx, name, password)
+}
+
+func (a *timeoutAuth) UserGrantRole(ctx context.Context, user string, role string) (*clientv3.AuthUserGrantRoleResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.UserGrantRole(ctx, user, role)
+}
+
+func (a *timeoutAuth) UserGet(ctx context.Context, name string) (*clientv3.AuthUserGetResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.UserGet(ctx, name)
+}
+
+func (a *timeoutAuth) UserList(ctx context.Context) (*clientv3.AuthUserListResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.UserList(ctx)
+}
+
+func (a *timeoutAuth) UserRevokeRole(ctx context.Context, name string, role string) (*clientv3.AuthUserRevokeRoleResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.UserRevokeRole(ctx, name, role)
+}
+
+func (a *timeoutAuth) RoleAdd(ctx context.Context, name string) (*clientv3.AuthRoleAddResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.RoleAdd(ctx, name)
+}
+
+func (a *timeoutAuth) RoleGrantPermission(ctx context.Context, name string, key, rangeEnd string, permType clientv3.PermissionType) (*clientv3.AuthRoleGrantPermissionResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.RoleGrantPermission(ctx, name, key, rangeEnd, permType)
+}
+
+func (a *timeoutAuth) RoleGet(ctx context.Context, role string) (*clientv3.AuthRoleGetResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.RoleGet(ctx, role)
+}
+
+func (a *timeoutAuth) RoleList(ctx context.Context) (*clientv3.AuthRoleListResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.RoleList(ctx)
+}
+
+func (a *timeoutAuth) RoleRevokePermission(ctx context.Context, role string, key, rangeEnd string) (*clientv3.AuthRoleRevokePermissionResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.RoleRevokePermission(ctx, role, key, rangeEnd)
+}
+
+func (a *timeoutAuth) RoleDelete(ctx context.Context, role string) (*clientv3.AuthRoleDeleteResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.Auth.RoleDelete(ctx, role)
+}
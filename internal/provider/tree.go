@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// treeLeafKey holds the value of a key that is also the parent of other keys,
+// e.g. "/app" when "/app/port" exists too.
+const treeLeafKey = ""
+
+// buildTree splits every key of kv on delimiter and nests the values into a
+// tree of maps. Empty path segments are ignored, so "/a//b" and "a/b" land on
+// the same leaf. The keys are nested in sorted order, so of the keys landing on
+// the same leaf the last one wins, whatever the order of kv.
+func buildTree(kv map[string]string, delimiter string) map[string]interface{} {
+	root := make(map[string]interface{})
+
+	keys := make([]string, 0, len(kv))
+	for key := range kv {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := kv[key]
+		segments := splitKey(key, delimiter)
+		if len(segments) == 0 {
+			root[treeLeafKey] = value
+			continue
+		}
+
+		node := root
+		for _, s := range segments[:len(segments)-1] {
+			switch child := node[s].(type) {
+			case map[string]interface{}:
+				node = child
+			case string:
+				next := map[string]interface{}{treeLeafKey: child}
+				node[s] = next
+				node = next
+			default:
+				next := make(map[string]interface{})
+				node[s] = next
+				node = next
+			}
+		}
+
+		last := segments[len(segments)-1]
+		if child, ok := node[last].(map[string]interface{}); ok {
+			child[treeLeafKey] = value
+		} else {
+			node[last] = value
+		}
+	}
+
+	return root
+}
+
+func splitKey(key, delimiter string) []string {
+	var segments []string
+	for _, s := range strings.Split(key, delimiter) {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// flattenTree is the inverse of buildTree: it walks the decoded document doc and
+// returns its values indexed by the path leading to them, joined with
+// delimiter. Array elements are indexed by their position, the value under the
+// empty attribute name belongs to the path of its object, and null values are
+// left out.
+func flattenTree(doc interface{}, delimiter string) (map[string]string, error) {
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("the document must be an object, got: %s", jsonString(doc))
+	}
+	kv := make(map[string]string)
+	if err := flattenNode(doc, nil, delimiter, kv); err != nil {
+		return nil, err
+	}
+	return kv, nil
+}
+
+func flattenNode(node interface{}, path []string, delimiter string, kv map[string]string) error {
+	switch n := node.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, v := range n {
+			if k == treeLeafKey {
+				switch v.(type) {
+				case map[string]interface{}, []interface{}:
+					return fmt.Errorf("the empty attribute of %q must hold a value, not an object or an array", strings.Join(path, delimiter))
+				}
+				if err := flattenNode(v, path, delimiter, kv); err != nil {
+					return err
+				}
+				continue
+			}
+			if strings.Contains(k, delimiter) {
+				return fmt.Errorf("attribute %q contains the delimiter %q", k, delimiter)
+			}
+			if err := flattenNode(v, append(path[:len(path):len(path)], k), delimiter, kv); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, v := range n {
+			if err := flattenNode(v, append(path[:len(path):len(path)], strconv.Itoa(i)), delimiter, kv); err != nil {
+				return err
+			}
+		}
+	case string:
+		kv[strings.Join(path, delimiter)] = n
+	default:
+		kv[strings.Join(path, delimiter)] = jsonString(n)
+	}
+	return nil
+}
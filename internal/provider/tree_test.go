@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildTree(t *testing.T) {
+	cases := []struct {
+		name      string
+		kv        map[string]string
+		delimiter string
+		want      map[string]interface{}
+	}{
+		{
+			name:      "empty",
+			kv:        map[string]string{},
+			delimiter: "/",
+			want:      map[string]interface{}{},
+		},
+		{
+			name:      "nested",
+			kv:        map[string]string{"a/b": "1", "a/c/d": "2", "e": "3"},
+			delimiter: "/",
+			want: map[string]interface{}{
+				"a": map[string]interface{}{"b": "1", "c": map[string]interface{}{"d": "2"}},
+				"e": "3",
+			},
+		},
+		{
+			name:      "parent with a value",
+			kv:        map[string]string{"a": "1", "a/b": "2"},
+			delimiter: "/",
+			want: map[string]interface{}{
+				"a": map[string]interface{}{treeLeafKey: "1", "b": "2"},
+			},
+		},
+		{
+			name:      "empty key",
+			kv:        map[string]string{"": "1", "/": "2"},
+			delimiter: "/",
+			want:      map[string]interface{}{treeLeafKey: "2"},
+		},
+		{
+			name:      "colliding keys, the last one in key order wins",
+			kv:        map[string]string{"/a//b": "1", "/a/b": "2", "a/b": "3"},
+			delimiter: "/",
+			want: map[string]interface{}{
+				"a": map[string]interface{}{"b": "3"},
+			},
+		},
+		{
+			name:      "other delimiter",
+			kv:        map[string]string{"a.b": "1", "a/b": "2"},
+			delimiter: ".",
+			want: map[string]interface{}{
+				"a":   map[string]interface{}{"b": "1"},
+				"a/b": "2",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// map iteration order is random, build the tree a few times
+			for i := 0; i < 10; i++ {
+				if got := buildTree(c.kv, c.delimiter); !reflect.DeepEqual(got, c.want) {
+					t.Fatalf("buildTree(%v) = %v, want %v", c.kv, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitKey(t *testing.T) {
+	cases := []struct {
+		key  string
+		want []string
+	}{
+		{key: "", want: nil},
+		{key: "/", want: nil},
+		{key: "a", want: []string{"a"}},
+		{key: "/a/b/", want: []string{"a", "b"}},
+		{key: "a//b", want: []string{"a", "b"}},
+	}
+
+	for _, c := range cases {
+		if got := splitKey(c.key, "/"); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("splitKey(%q) = %q, want %q", c.key, got, c.want)
+		}
+	}
+}
+
+func TestFlattenTree(t *testing.T) {
+	cases := []struct {
+		name      string
+		doc       string
+		delimiter string
+		want      map[string]string
+		err       bool
+	}{
+		{
+			name:      "nested",
+			doc:       `{"a": {"b": "1", "c": {"d": 2}}, "e": true}`,
+			delimiter: "/",
+			want:      map[string]string{"a/b": "1", "a/c/d": "2", "e": "true"},
+		},
+		{
+			name:      "arrays indexed by position",
+			doc:       `{"a": ["x", {"b": "y"}]}`,
+			delimiter: "/",
+			want:      map[string]string{"a/0": "x", "a/1/b": "y"},
+		},
+		{
+			name:      "parent with a value",
+			doc:       `{"a": {"": "1", "b": "2"}}`,
+			delimiter: "/",
+			want:      map[string]string{"a": "1", "a/b": "2"},
+		},
+		{
+			name:      "null values left out",
+			doc:       `{"a": null, "b": "1"}`,
+			delimiter: "/",
+			want:      map[string]string{"b": "1"},
+		},
+		{
+			name:      "numbers as written",
+			doc:       `{"a": 9007199254740993, "b": 1.50}`,
+			delimiter: "/",
+			want:      map[string]string{"a": "9007199254740993", "b": "1.50"},
+		},
+		{
+			name:      "other delimiter",
+			doc:       `{"a": {"b/c": "1"}}`,
+			delimiter: ".",
+			want:      map[string]string{"a.b/c": "1"},
+		},
+		{
+			name:      "attribute containing the delimiter",
+			doc:       `{"a/b": "1"}`,
+			delimiter: "/",
+			err:       true,
+		},
+		{
+			name:      "object under the empty attribute",
+			doc:       `{"a": {"": {"b": "1"}}}`,
+			delimiter: "/",
+			err:       true,
+		},
+		{
+			name:      "not an object",
+			doc:       `["a"]`,
+			delimiter: "/",
+			err:       true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc, err := decodeJSON([]byte(c.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := flattenTree(doc, c.delimiter)
+			if c.err {
+				if err == nil {
+					t.Fatalf("flattenTree(%s) = %v, want an error", c.doc, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("flattenTree(%s) = %v, want %v", c.doc, got, c.want)
+			}
+		})
+	}
+}
+
+func TestFlattenTreeRoundTrip(t *testing.T) {
+	// buildTree reads back the keys flattenTree writes
+	kv := map[string]string{"a": "1", "a/b": "2", "a/c/d": "3", "e": "4"}
+	got, err := flattenTree(buildTree(kv, "/"), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, kv) {
+		t.Errorf("flattenTree(buildTree(%v)) = %v", kv, got)
+	}
+}
@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// txnChunkSize is the number of operations sent in a single transaction, well
+// under the 128 operations etcd accepts by default (--max-txn-ops).
+const txnChunkSize = 64
+
+// commitOps applies ops in transactions of at most txnChunkSize operations.
+// Each transaction is atomic, the whole set is not.
+func commitOps(ctx context.Context, kv clientv3.KV, ops []clientv3.Op) error {
+	for len(ops) > 0 {
+		n := len(ops)
+		if n > txnChunkSize {
+			n = txnChunkSize
+		}
+		if _, err := kv.Txn(ctx).Then(ops[:n]...).Commit(); err != nil {
+			return err
+		}
+		ops = ops[n:]
+	}
+	return nil
+}
+
+// moveKey atomically writes value to the key to and deletes the key from, so
+// readers see either key but never none. It refuses to overwrite an existing
+// key.
+func moveKey(ctx context.Context, kv clientv3.KV, from, to, value string) error {
+	resp, err := kv.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(to), "=", 0)).
+		Then(clientv3.OpPut(to, value), clientv3.OpDelete(from)).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return fmt.Errorf("cannot move key %q to %q, the key already exists", from, to)
+	}
+	return nil
+}
+
+// movePrefix moves every key under from to the same relative key under to,
+// each key being written to its new place and deleted from the old one in the
+// same transaction. The keys are read at a single revision, and every
+// transaction is guarded on no key under from being written since, so a key
+// written meanwhile aborts the move instead of being lost. check is called for
+// every key before anything is moved. It returns the number of keys moved.
+func movePrefix(ctx context.Context, kv clientv3.KV, from, to string, check func(key string) error) (int, error) {
+	fromEnd := clientv3.GetPrefixRangeEnd(from)
+	if rangesOverlap(from, fromEnd, to, clientv3.GetPrefixRangeEnd(to)) {
+		return 0, fmt.Errorf("cannot move the keys under %q to %q, the prefixes overlap", from, to)
+	}
+
+	kvs, rev, err := getRange(ctx, kv, rangeQuery{key: from, rangeEnd: fromEnd})
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range kvs {
+		if err := check(string(e.Key)); err != nil {
+			return 0, err
+		}
+	}
+
+	unchanged := clientv3.Compare(clientv3.ModRevision(from), "<", rev+1).WithRange(fromEnd)
+	moved := 0
+	// every key takes a put and a delete
+	for chunk := txnChunkSize / 2; len(kvs) > 0; kvs = kvs[chunk:] {
+		if chunk > len(kvs) {
+			chunk = len(kvs)
+		}
+
+		ops := make([]clientv3.Op, 0, 2*chunk)
+		for _, e := range kvs[:chunk] {
+			key := string(e.Key)
+			ops = append(ops, clientv3.OpPut(to+strings.TrimPrefix(key, from), string(e.Value)), clientv3.OpDelete(key))
+		}
+
+		resp, err := kv.Txn(ctx).If(unchanged).Then(ops...).Commit()
+		if err != nil {
+			return moved, err
+		}
+		if !resp.Succeeded {
+			return moved, fmt.Errorf("keys under %q changed while they were moved to %q, %d keys were moved", from, to, moved)
+		}
+		moved += chunk
+	}
+	return moved, nil
+}
+
+// updateMaxAttempts is the number of times updateKey reads and writes a key
+// written concurrently before giving up.
+const updateMaxAttempts = 10
+
+// updateKey replaces the value of key by the one returned by update, called
+// with the current value, or nil when the key does not exist. The write is
+// guarded on the key being unchanged since it was read, and the whole read,
+// update and write runs again when it was. update returning the value it was
+// given skips the write. It returns the revision of the value written.
+func updateKey(ctx context.Context, kv clientv3.KV, key string, update func(value []byte) ([]byte, error)) (int64, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := kv.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		var current []byte
+		var modRevision int64
+		if len(resp.Kvs) > 0 {
+			current = resp.Kvs[0].Value
+			modRevision = resp.Kvs[0].ModRevision
+		}
+
+		value, err := update(current)
+		if err != nil {
+			return 0, err
+		}
+		if value == nil || (current != nil && string(value) == string(current)) {
+			return modRevision, nil
+		}
+
+		txnResp, err := kv.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", modRevision)).
+			Then(clientv3.OpPut(key, string(value))).
+			Commit()
+		if err != nil {
+			return 0, err
+		}
+		if txnResp.Succeeded {
+			return txnResp.Header.Revision, nil
+		}
+		if attempt >= updateMaxAttempts {
+			return 0, fmt.Errorf("key %q kept changing while it was updated, giving up after %d attempts", key, attempt)
+		}
+	}
+}
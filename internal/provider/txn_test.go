@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// recordingKV records the operations of the transactions committed through it,
+// failing the transaction of index fail.
+type recordingKV struct {
+	clientv3.KV
+	txns [][]clientv3.Op
+	fail int
+}
+
+func (kv *recordingKV) Txn(context.Context) clientv3.Txn {
+	return &recordingTxn{kv: kv}
+}
+
+type recordingTxn struct {
+	clientv3.Txn
+	kv  *recordingKV
+	ops []clientv3.Op
+}
+
+func (txn *recordingTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	txn.ops = append(txn.ops, ops...)
+	return txn
+}
+
+func (txn *recordingTxn) Commit() (*clientv3.TxnResponse, error) {
+	if len(txn.kv.txns) == txn.kv.fail {
+		return nil, errors.New("txn failed")
+	}
+	txn.kv.txns = append(txn.kv.txns, txn.ops)
+	return &clientv3.TxnResponse{Succeeded: true}, nil
+}
+
+func TestCommitOps(t *testing.T) {
+	cases := []struct {
+		ops   int
+		sizes []int
+	}{
+		{ops: 0, sizes: nil},
+		{ops: 1, sizes: []int{1}},
+		{ops: txnChunkSize, sizes: []int{txnChunkSize}},
+		{ops: txnChunkSize + 1, sizes: []int{txnChunkSize, 1}},
+		{ops: 2*txnChunkSize + 10, sizes: []int{txnChunkSize, txnChunkSize, 10}},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%d ops", c.ops), func(t *testing.T) {
+			ops := make([]clientv3.Op, c.ops)
+			for i := range ops {
+				ops[i] = clientv3.OpPut(fmt.Sprintf("key%d", i), "value")
+			}
+
+			kv := &recordingKV{fail: -1}
+			if err := commitOps(context.Background(), kv, ops); err != nil {
+				t.Fatal(err)
+			}
+			if len(kv.txns) != len(c.sizes) {
+				t.Fatalf("committed %d transactions, want %d", len(kv.txns), len(c.sizes))
+			}
+			next := 0
+			for i, txn := range kv.txns {
+				if len(txn) != c.sizes[i] {
+					t.Errorf("transaction %d has %d operations, want %d", i, len(txn), c.sizes[i])
+				}
+				// the operations are committed in order
+				for _, op := range txn {
+					if want := fmt.Sprintf("key%d", next); string(op.KeyBytes()) != want {
+						t.Fatalf("operation on %q, want %q", op.KeyBytes(), want)
+					}
+					next++
+				}
+			}
+		})
+	}
+}
+
+func TestCommitOpsStopsOnError(t *testing.T) {
+	ops := make([]clientv3.Op, 3*txnChunkSize)
+	for i := range ops {
+		ops[i] = clientv3.OpDelete(fmt.Sprintf("key%d", i))
+	}
+
+	kv := &recordingKV{fail: 1}
+	if err := commitOps(context.Background(), kv, ops); err == nil {
+		t.Fatal("commitOps succeeded, want the error of the second transaction")
+	}
+	if len(kv.txns) != 1 {
+		t.Errorf("committed %d transactions, want 1", len(kv.txns))
+	}
+}